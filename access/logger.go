@@ -48,12 +48,16 @@ func CustomLogger(loggerFunc LogWriterFunc) routing.Handler {
 		startTime := time.Now()
 
 		req := c.Request
-		rw := &LogResponseWriter{c.ResponseWriter, http.StatusOK, 0}
-		c.ResponseWriter = rw
+		res := c.Response()
+		if res == nil {
+			res = routing.NewResponse(c.ResponseWriter)
+			c.ResponseWriter = res
+		}
 
 		err := c.Next()
 
 		elapsed := float64(time.Now().Sub(startTime).Nanoseconds()) / 1e6
+		rw := &LogResponseWriter{res, res.Status(), res.Size()}
 		loggerFunc(req, rw, elapsed)
 
 		return err
@@ -82,18 +86,30 @@ func Logger(log LogFunc) routing.Handler {
 }
 
 // LogResponseWriter wraps http.ResponseWriter in order to capture HTTP status and response length information.
+// CustomLogger creates it from routing.Context.Response after the request is served instead of installing it
+// as the response writer, so that the optional interfaces of the response writer, such as http.Flusher, remain
+// available to the handlers. Status and BytesWritten are thus those tracked by routing.Response, including
+// the data written to a streamed response.
 type LogResponseWriter struct {
 	http.ResponseWriter
 	Status       int
 	BytesWritten int64
 }
 
+// Write writes the data to the wrapped writer and adds its length to BytesWritten.
+//
+// Deprecated: CustomLogger no longer writes through LogResponseWriter. Write to routing.Context.Response instead,
+// which tracks the size of the response.
 func (r *LogResponseWriter) Write(p []byte) (int, error) {
 	written, err := r.ResponseWriter.Write(p)
 	r.BytesWritten += int64(written)
 	return written, err
 }
 
+// WriteHeader sends the status to the wrapped writer and records it in Status.
+//
+// Deprecated: CustomLogger no longer writes through LogResponseWriter. Write to routing.Context.Response instead,
+// which tracks the status of the response.
 func (r *LogResponseWriter) WriteHeader(status int) {
 	r.Status = status
 	r.ResponseWriter.WriteHeader(status)
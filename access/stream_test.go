@@ -0,0 +1,53 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package access
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ltick/tick-routing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomLoggerStreamedResponse(t *testing.T) {
+	var (
+		status int
+		size   int64
+	)
+	r := routing.New()
+	r.Use(CustomLogger(func(req *http.Request, rw *LogResponseWriter, elapsed float64) {
+		status, size = rw.Status, rw.BytesWritten
+	}))
+	r.Get("/stream", func(c *routing.Context) error {
+		c.Response().WriteHeader(http.StatusAccepted)
+		c.Response().Flush()
+		fmt.Fprint(c.ResponseWriter, "abc")
+		c.Response().Flush()
+		fmt.Fprint(c.Response(), "de")
+		return nil
+	})
+	r.Get("/flush", func(c *routing.Context) error {
+		c.Response().Flush()
+		fmt.Fprint(c.ResponseWriter, "abc")
+		return nil
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/stream", nil)
+	r.ServeHTTP(res, req)
+	assert.True(t, res.Flushed)
+	assert.Equal(t, "abcde", res.Body.String())
+	assert.Equal(t, http.StatusAccepted, status)
+	assert.Equal(t, int64(5), size)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/flush", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, int64(3), size)
+}
@@ -0,0 +1,125 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+// bindSources lists the struct tags recognized by Context.Bind and the request data they refer to.
+var bindSources = []string{"path", "query", "header", "cookie"}
+
+// Bind populates the given struct variable with the data from the current request and validates it.
+//
+// The request body is first read in the same way as Read, using the "json", "xml" or "form" tags
+// of the struct fields. An empty body is ignored. Then the fields with the following tags
+// are populated, overwriting the values read from the body:
+//
+//   - path: the named route parameter, such as `path:"id"`
+//   - query: the named URL query parameter, such as `query:"page"`
+//   - header: the named HTTP header, such as `header:"X-Tenant"`
+//   - cookie: the value of the named cookie, such as `cookie:"sid"`
+//
+// The values are converted in the same way as ReadFormData, including the support for encoding.TextUnmarshaler
+// and the "layout" and "default" tags. Slice fields receive all values of a query parameter or header.
+// If a value cannot be converted, a 400 HTTP error is returned. Finally, the data is validated using DefaultValidator.
+func (c *Context) Bind(data interface{}) error {
+	rv := reflect.ValueOf(data)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("data must be a pointer")
+	}
+	if indirect(rv).Kind() != reflect.Struct {
+		return errors.New("data must be a pointer to a struct")
+	}
+
+	if err := c.read(data); err != nil && err != io.EOF {
+		return err
+	}
+
+	values := map[string]map[string][]string{
+		"path":   c.bindPathValues(),
+		"query":  normalizeFormKeys(c.Request.URL.Query()),
+		"header": c.Request.Header,
+		"cookie": bindCookieValues(c.Request.Cookies()),
+	}
+	if err := bindFields(values, rv); err != nil {
+		return err
+	}
+	return validate(data)
+}
+
+// bindPathValues returns the route parameters as form values.
+func (c *Context) bindPathValues() map[string][]string {
+	values := make(map[string][]string, len(c.pnames))
+	for i, name := range c.pnames {
+		values[name] = []string{c.pvalues[i]}
+	}
+	return values
+}
+
+// bindCookieValues returns the values of the cookies indexed by their names.
+func bindCookieValues(cookies []*http.Cookie) map[string][]string {
+	values := make(map[string][]string, len(cookies))
+	for _, cookie := range cookies {
+		values[cookie.Name] = append(values[cookie.Name], cookie.Value)
+	}
+	return values
+}
+
+// bindFields populates the fields of the struct that have a tag in bindSources with the corresponding values.
+// Struct fields without such tags are populated recursively.
+func bindFields(values map[string]map[string][]string, rv reflect.Value) error {
+	rv = indirect(rv)
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+
+		// only handle anonymous or exported fields
+		if !field.Anonymous && field.PkgPath != "" {
+			continue
+		}
+
+		source, name := "", ""
+		for _, s := range bindSources {
+			if name = field.Tag.Get(s); name != "" {
+				source = s
+				break
+			}
+		}
+		if source == "" || name == "-" {
+			if field.Type.Kind() == reflect.Struct && name != "-" {
+				if err := bindFields(values, rv.Field(i)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if source == "header" {
+			name = http.CanonicalHeaderKey(name)
+		}
+		form := values[source]
+		ok, err := readFormFieldValue(form, name, field, rv.Field(i))
+		if err == nil && !ok {
+			err = readForm(form, name, rv.Field(i))
+		}
+		if err != nil {
+			return invalidBindError(source, name, err)
+		}
+	}
+	return nil
+}
+
+// invalidBindError returns a 400 HTTP error indicating a request value cannot be bound to a struct field.
+func invalidBindError(source, name string, err error) HTTPError {
+	if fe, ok := err.(*FormFieldError); ok {
+		name, err = fe.Key, fe.Err
+	}
+	return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid %v value %q: %v", source, name, err))
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type bindPaging struct {
+	Page int `query:"page"`
+	Size int `query:"size" validate:"max=100"`
+}
+
+type bindRequest struct {
+	ID      int       `path:"id"`
+	Tenant  string    `header:"x-tenant"`
+	Accept  []string  `header:"Accept"`
+	Session string    `cookie:"sid"`
+	Since   time.Time `query:"since"`
+	Tags    []string  `query:"tag"`
+	Name    string    `json:"name" validate:"required"`
+	Ignored string    `query:"-"`
+	bindPaging
+}
+
+func TestContextBind(t *testing.T) {
+	var (
+		data bindRequest
+		err  error
+	)
+	r := New()
+	r.Put("/users/<id>", func(c *Context) error {
+		data = bindRequest{}
+		err = c.Bind(&data)
+		return nil
+	})
+
+	serve := func(body string) {
+		req, _ := http.NewRequest("PUT", "/users/12?page=2&tag=a&tag=b&since=2016-01-02T03:04:05Z&Ignored=x", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Tenant", "acme")
+		req.Header.Add("Accept", "text/html")
+		req.Header.Add("Accept", "application/json")
+		req.AddCookie(&http.Cookie{Name: "sid", Value: "abc"})
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	serve(`{"name":"John"}`)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 12, data.ID)
+		assert.Equal(t, "acme", data.Tenant)
+		assert.Equal(t, []string{"text/html", "application/json"}, data.Accept)
+		assert.Equal(t, "abc", data.Session)
+		assert.Equal(t, time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC), data.Since)
+		assert.Equal(t, []string{"a", "b"}, data.Tags)
+		assert.Equal(t, "John", data.Name)
+		assert.Equal(t, "", data.Ignored)
+		assert.Equal(t, 2, data.Page)
+	}
+
+	// an empty body is ignored but the data is still validated
+	serve("")
+	assert.Equal(t, ValidationErrors{{"name", "required", "is required"}}, err)
+	assert.Equal(t, 12, data.ID)
+
+	req, _ := http.NewRequest("GET", "/?page=abc", nil)
+	c := NewContext(nil, req)
+	var paging bindPaging
+	err = c.Bind(&paging)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusBadRequest, err.(HTTPError).StatusCode())
+	}
+
+	req, _ = http.NewRequest("GET", "/?size=200", nil)
+	c = NewContext(nil, req)
+	assert.Equal(t, ValidationErrors{{"Size", "max", "must be no greater than 100"}}, c.Bind(&paging))
+
+	assert.NotNil(t, c.Bind(paging))
+	var i int
+	assert.NotNil(t, c.Bind(&i))
+}
@@ -0,0 +1,199 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RouteConflictError describes a route that can never be matched because of a route registered earlier.
+type RouteConflictError struct {
+	Route     *Route // the route that can never be matched
+	Previous  *Route // the route registered earlier that always matches first
+	Duplicate bool   // whether both routes have the same method and pattern
+}
+
+// Error returns the error message.
+func (e *RouteConflictError) Error() string {
+	if e.Duplicate {
+		return fmt.Sprintf("route %v is already registered", e.Route)
+	}
+	return fmt.Sprintf("route %v can never be matched because route %v is registered earlier", e.Route, e.Previous)
+}
+
+// Validate checks the routes registered with the router and returns an error for every route
+// that duplicates an earlier route or is shadowed by an earlier parametric or wildcard route.
+// The check is conservative: a route is only reported when it is certain that it cannot be matched.
+// Routes with regular expression parameters are only compared with routes using the same regular expressions.
+func (r *Router) Validate() []error {
+	var errs []error
+	routes := r.Routes()
+	for i, route := range routes {
+		if err := findConflict(route, routes[:i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// findConflict returns an error if the route cannot be matched because of one of the given earlier routes.
+func findConflict(route *Route, routes []*Route) *RouteConflictError {
+	pattern := routePattern(route)
+	elements := parsePattern(pattern)
+	for _, previous := range routes {
+		if previous.method != route.method || previous.group.host != route.group.host {
+			continue
+		}
+		p := routePattern(previous)
+		if p == pattern {
+			return &RouteConflictError{Route: route, Previous: previous, Duplicate: true}
+		}
+		if coversPattern(parsePattern(p), elements) {
+			return &RouteConflictError{Route: route, Previous: previous}
+		}
+	}
+	return nil
+}
+
+// routePattern returns the full pattern of the route as it is stored in the router.
+func routePattern(route *Route) string {
+	path := route.group.prefix + route.path
+	if strings.HasSuffix(path, "*") {
+		path = path[:len(path)-1] + "<:.*>"
+	}
+	return path
+}
+
+// patternElement is either a static string or a parameter token of a route pattern.
+type patternElement struct {
+	static  string // the static string, empty for a parameter token
+	param   bool   // whether the element is a parameter token
+	name    string // the name of the parameter token
+	pattern string // the pattern of the parameter token, empty if the token matches any non-slash characters
+}
+
+// parsePattern splits a route pattern into static strings and parameter tokens.
+func parsePattern(pattern string) []patternElement {
+	var elements []patternElement
+	start, end := -1, 0
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == '<' && start < 0 {
+			start = i
+		} else if pattern[i] == '>' && start >= 0 {
+			if start > end {
+				elements = append(elements, patternElement{static: pattern[end:start]})
+			}
+			e := patternElement{param: true, name: pattern[start+1 : i]}
+			if j := strings.IndexByte(e.name, ':'); j >= 0 {
+				e.name, e.pattern = e.name[:j], e.name[j+1:]
+			}
+			elements = append(elements, e)
+			start, end = -1, i+1
+		}
+	}
+	if end < len(pattern) {
+		elements = append(elements, patternElement{static: pattern[end:]})
+	}
+	return elements
+}
+
+// coversPattern checks if every path matching the pattern b also matches the pattern a.
+func coversPattern(a, b []patternElement) bool {
+	if len(a) == 0 {
+		return len(b) == 0
+	}
+	e := a[0]
+	switch {
+	case !e.param:
+		if len(b) == 0 || b[0].param {
+			return false
+		}
+		s, t := e.static, b[0].static
+		if strings.HasPrefix(t, s) {
+			return coversPattern(a[1:], replaceFirst(b, t[len(s):]))
+		}
+		if strings.HasPrefix(s, t) {
+			return coversPattern(replaceFirst(a, s[len(t):]), b[1:])
+		}
+		return false
+	case e.pattern == ".*":
+		// a wildcard token matches everything only at the end of the pattern
+		return len(a) == 1
+	case e.pattern == "":
+		// consume the elements of b up to the next slash
+		consumed := false
+		for len(b) > 0 {
+			if !b[0].param {
+				i := strings.IndexByte(b[0].static, '/')
+				if i == 0 {
+					break
+				}
+				if i > 0 {
+					b = replaceFirst(b, b[0].static[i:])
+					consumed = true
+					break
+				}
+			} else if _, ok := paramMatchers[b[0].pattern]; !ok && b[0].pattern != "" {
+				// a regular expression may match slashes
+				return false
+			}
+			b = b[1:]
+			consumed = true
+		}
+		return consumed && coversPattern(a[1:], b)
+	default:
+		if len(b) == 0 || !b[0].param || b[0].pattern != e.pattern {
+			return false
+		}
+		return coversPattern(a[1:], b[1:])
+	}
+}
+
+// disjointPatterns checks if no path can match both patterns a and b.
+// The check is conservative: only patterns whose leading or trailing static strings differ are reported as disjoint.
+func disjointPatterns(a, b []patternElement) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) != len(b)
+	}
+	pa, pb := staticPrefix(a), staticPrefix(b)
+	if !strings.HasPrefix(pa, pb) && !strings.HasPrefix(pb, pa) {
+		return true
+	}
+	if len(a) == 1 && len(b) == 1 && !a[0].param && !b[0].param {
+		// both patterns are static strings
+		return pa != pb
+	}
+	sa, sb := staticSuffix(a), staticSuffix(b)
+	return !strings.HasSuffix(sa, sb) && !strings.HasSuffix(sb, sa)
+}
+
+// staticPrefix returns the static string the pattern elements start with.
+func staticPrefix(elements []patternElement) string {
+	if elements[0].param {
+		return ""
+	}
+	return elements[0].static
+}
+
+// staticSuffix returns the static string the pattern elements end with.
+func staticSuffix(elements []patternElement) string {
+	if e := elements[len(elements)-1]; !e.param {
+		return e.static
+	}
+	return ""
+}
+
+// replaceFirst returns the elements with the first one replaced by the given static string.
+// The first element is removed if the string is empty.
+func replaceFirst(elements []patternElement, static string) []patternElement {
+	if static == "" {
+		return elements[1:]
+	}
+	result := make([]patternElement, len(elements))
+	copy(result, elements)
+	result[0] = patternElement{static: static}
+	return result
+}
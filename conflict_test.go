@@ -0,0 +1,97 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoversPattern(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"/users", "/users", true},
+		{"/users", "/users/", false},
+		{"/users/<id>", "/users/profile", true},
+		{"/users/<id>", "/users/<name>", true},
+		{"/users/<id>", "/users/<id:int>", true},
+		{"/users/<id>", "/users/<id:\\d+>", false},
+		{"/users/<id>", "/users/<id>/profile", false},
+		{"/users/<id>", "/users/accnt-<id:int>", true},
+		{"/users/<id>/profile", "/users/abc/profile", true},
+		{"/users/<id>/profile", "/users/abc/address", false},
+		{"/users/<id:\\d+>", "/users/<name:\\d+>", true},
+		{"/users/<id:\\d+>", "/users/123", false},
+		{"/users/<:.*>", "/users/<id>/profile/<:.*>", true},
+		{"/users/<:.*>", "/users", false},
+		{"/users/<:.*>/x", "/users/a/x", false},
+		{"/users/profile", "/users/<id>", false},
+		{"/users/<id>", "/users/", false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, coversPattern(parsePattern(test.a), parsePattern(test.b)), test.a+" covers "+test.b)
+	}
+}
+
+func TestDisjointPatterns(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"/users", "/items", true},
+		{"/users", "/users", false},
+		{"/users/<id>", "/items/<id>", true},
+		{"/users/<id>", "/users/new", false},
+		{"/users/<id>/profile", "/users/<id>/address", true},
+		{"/users/<id>", "/users/<id>/profile", false},
+		{"/users/<:.*>", "/users/a/b", false},
+		{"<:.*>", "/users", false},
+		{"", "/users", true},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, disjointPatterns(parsePattern(test.a), parsePattern(test.b)), test.a+" and "+test.b)
+	}
+}
+
+func TestRouterValidate(t *testing.T) {
+	r := New()
+	r.Get("/users/<id>")
+	r.Get("/users/profile")
+	r.Post("/users/profile")
+	r.Get("/users/<id>")
+	api := r.Group("/api")
+	api.Get("/*")
+	api.Get("/items/<id:int>")
+	r.Host("admin.example.com").Get("/users/profile")
+
+	errs := r.Validate()
+	if assert.Equal(t, 3, len(errs)) {
+		assert.Equal(t, "route GET /users/profile can never be matched because route GET /users/<id> is registered earlier", errs[0].Error())
+		assert.Equal(t, "route GET /users/<id> is already registered", errs[1].Error())
+		assert.True(t, errs[1].(*RouteConflictError).Duplicate)
+		assert.Equal(t, "route GET /api/items/<id:int> can never be matched because route GET /api/* is registered earlier", errs[2].Error())
+	}
+}
+
+func TestRouterStrict(t *testing.T) {
+	r := New()
+	r.Strict = true
+	r.Get("/users/<id:int>")
+	r.Get("/users/<name>")
+	assert.Panics(t, func() {
+		r.Get("/users/<id:int>")
+	})
+	assert.Panics(t, func() {
+		r.Get("/users/123")
+	})
+	assert.NotPanics(t, func() {
+		r.Post("/users/123")
+	})
+	assert.Equal(t, 3, len(r.Routes()))
+	assert.Equal(t, 0, len(r.Validate()))
+}
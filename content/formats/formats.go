@@ -0,0 +1,153 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package formats provides data readers and writers for the YAML, MessagePack, Protocol Buffers and CBOR formats.
+// They are kept out of the routing and content packages so that only the applications using these formats
+// depend on the libraries implementing them.
+package formats
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/ltick/tick-routing"
+	"github.com/ltick/tick-routing/content"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// Register adds the data readers of the formats to routing.DataReaders and the data writers
+// to content.DataWriters. It should be called before the router starts serving requests. For example,
+//
+//	formats.Register()
+//	router.Use(content.TypeNegotiator(content.JSON, content.MSGPACK, content.PROTOBUF))
+func Register() {
+	routing.DataReaders[routing.MIME_YAML] = &YAMLDataReader{}
+	routing.DataReaders[routing.MIME_YAML2] = &YAMLDataReader{}
+	routing.DataReaders[routing.MIME_MSGPACK] = &MsgpackDataReader{}
+	routing.DataReaders[routing.MIME_MSGPACK2] = &MsgpackDataReader{}
+	routing.DataReaders[routing.MIME_PROTOBUF] = &ProtobufDataReader{}
+	routing.DataReaders[routing.MIME_PROTOBUF2] = &ProtobufDataReader{}
+	routing.DataReaders[routing.MIME_CBOR] = &CBORDataReader{}
+
+	content.DataWriters[content.YAML] = &YAMLDataWriter{}
+	content.DataWriters[content.YAML2] = &YAMLDataWriter{}
+	content.DataWriters[content.MSGPACK] = &MsgpackDataWriter{}
+	content.DataWriters[content.MSGPACK2] = &MsgpackDataWriter{}
+	content.DataWriters[content.PROTOBUF] = &ProtobufDataWriter{}
+	content.DataWriters[content.PROTOBUF2] = &ProtobufDataWriter{}
+	content.DataWriters[content.CBOR] = &CBORDataWriter{}
+}
+
+// YAMLDataReader reads the request body as YAML-formatted data.
+// Struct fields are named after their "yaml" tags, or their lowercased names if there is no tag.
+type YAMLDataReader struct{}
+
+func (r *YAMLDataReader) Read(req *http.Request, data interface{}) error {
+	return yaml.NewDecoder(req.Body).Decode(data)
+}
+
+// MsgpackDataReader reads the request body as MessagePack-encoded data.
+// Struct fields are named after their "msgpack" tags, or their names if there is no tag.
+type MsgpackDataReader struct{}
+
+func (r *MsgpackDataReader) Read(req *http.Request, data interface{}) error {
+	return msgpack.NewDecoder(req.Body).Decode(data)
+}
+
+// ProtobufDataReader reads the request body as a Protocol Buffers message.
+// The data being populated must implement proto.Message.
+type ProtobufDataReader struct{}
+
+func (r *ProtobufDataReader) Read(req *http.Request, data interface{}) error {
+	message, ok := data.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%T does not implement proto.Message", data)
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(body, message)
+}
+
+// CBORDataReader reads the request body as CBOR-encoded data.
+// Struct fields are named after their "cbor" tags, their "json" tags, or their names if there is neither tag.
+type CBORDataReader struct{}
+
+func (r *CBORDataReader) Read(req *http.Request, data interface{}) error {
+	return cbor.NewDecoder(req.Body).Decode(data)
+}
+
+// YAMLDataWriter sets the "Content-Type" response header as "application/yaml" and writes the given data in YAML format to the response.
+type YAMLDataWriter struct{}
+
+func (w *YAMLDataWriter) SetHeader(res http.ResponseWriter) {
+	res.Header().Set("Content-Type", content.YAML)
+}
+
+func (w *YAMLDataWriter) Write(res http.ResponseWriter, data interface{}) (int, error) {
+	bytes, err := yaml.Marshal(data)
+	if err != nil {
+		return -1, err
+	}
+
+	return res.Write(bytes)
+}
+
+// MsgpackDataWriter sets the "Content-Type" response header as "application/msgpack" and writes the given data in MessagePack format to the response.
+type MsgpackDataWriter struct{}
+
+func (w *MsgpackDataWriter) SetHeader(res http.ResponseWriter) {
+	res.Header().Set("Content-Type", content.MSGPACK)
+}
+
+func (w *MsgpackDataWriter) Write(res http.ResponseWriter, data interface{}) (int, error) {
+	bytes, err := msgpack.Marshal(data)
+	if err != nil {
+		return -1, err
+	}
+
+	return res.Write(bytes)
+}
+
+// ProtobufDataWriter sets the "Content-Type" response header as "application/protobuf" and writes the given data as a Protocol Buffers message to the response.
+// The data must implement proto.Message.
+type ProtobufDataWriter struct{}
+
+func (w *ProtobufDataWriter) SetHeader(res http.ResponseWriter) {
+	res.Header().Set("Content-Type", content.PROTOBUF)
+}
+
+func (w *ProtobufDataWriter) Write(res http.ResponseWriter, data interface{}) (int, error) {
+	message, ok := data.(proto.Message)
+	if !ok {
+		return -1, fmt.Errorf("%T does not implement proto.Message", data)
+	}
+	bytes, err := proto.Marshal(message)
+	if err != nil {
+		return -1, err
+	}
+
+	return res.Write(bytes)
+}
+
+// CBORDataWriter sets the "Content-Type" response header as "application/cbor" and writes the given data in CBOR format to the response.
+type CBORDataWriter struct{}
+
+func (w *CBORDataWriter) SetHeader(res http.ResponseWriter) {
+	res.Header().Set("Content-Type", content.CBOR)
+}
+
+func (w *CBORDataWriter) Write(res http.ResponseWriter, data interface{}) (int, error) {
+	bytes, err := cbor.Marshal(data)
+	if err != nil {
+		return -1, err
+	}
+
+	return res.Write(bytes)
+}
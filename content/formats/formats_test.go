@@ -0,0 +1,87 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package formats
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ltick/tick-routing"
+	"github.com/ltick/tick-routing/content"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestDataReaders(t *testing.T) {
+	Register()
+	type item struct {
+		Name  string `yaml:"name" msgpack:"name" cbor:"name"`
+		Count int    `yaml:"count" msgpack:"count" cbor:"count"`
+	}
+	tests := []struct {
+		contentType string
+		body        string
+	}{
+		{routing.MIME_YAML, "name: abc\ncount: 2\n"},
+		{routing.MIME_YAML2, "{name: abc, count: 2}"},
+		{routing.MIME_MSGPACK, "\x82\xa4name\xa3abc\xa5count\x02"},
+		{routing.MIME_MSGPACK2, "\x82\xa4name\xa3abc\xa5count\x02"},
+		{routing.MIME_CBOR, "\xa2\x64name\x63abc\x65count\x02"},
+	}
+	for _, test := range tests {
+		var data item
+		req, _ := http.NewRequest("POST", "/test", strings.NewReader(test.body))
+		req.Header.Set("Content-Type", test.contentType)
+		err := routing.NewContext(nil, req).Read(&data)
+		assert.Nil(t, err, test.contentType)
+		assert.Equal(t, item{"abc", 2}, data, test.contentType)
+	}
+
+	for _, contentType := range []string{routing.MIME_PROTOBUF, routing.MIME_PROTOBUF2} {
+		var message wrapperspb.StringValue
+		req, _ := http.NewRequest("POST", "/test", strings.NewReader("\x0a\x03abc"))
+		req.Header.Set("Content-Type", contentType)
+		err := routing.NewContext(nil, req).Read(&message)
+		assert.Nil(t, err, contentType)
+		assert.Equal(t, "abc", message.Value, contentType)
+	}
+
+	var data item
+	req, _ := http.NewRequest("POST", "/test", strings.NewReader("\x0a\x03abc"))
+	req.Header.Set("Content-Type", routing.MIME_PROTOBUF)
+	assert.NotNil(t, routing.NewContext(nil, req).Read(&data))
+}
+
+func TestDataWriters(t *testing.T) {
+	data := map[string]interface{}{"name": "xyz"}
+	tests := []struct {
+		writer      routing.DataWriter
+		contentType string
+		body        string
+	}{
+		{&YAMLDataWriter{}, "application/yaml", "name: xyz\n"},
+		{&MsgpackDataWriter{}, "application/msgpack", "\x81\xa4name\xa3xyz"},
+		{&CBORDataWriter{}, "application/cbor", "\xa1\x64name\x63xyz"},
+		{&ProtobufDataWriter{}, "application/protobuf", "\x0a\x03xyz"},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		test.writer.SetHeader(res)
+		var err error
+		if test.contentType == content.PROTOBUF {
+			_, err = test.writer.Write(res, wrapperspb.String("xyz"))
+		} else {
+			_, err = test.writer.Write(res, data)
+		}
+		assert.Nil(t, err, test.contentType)
+		assert.Equal(t, test.contentType, res.Header().Get("Content-Type"))
+		assert.Equal(t, test.body, res.Body.String(), test.contentType)
+	}
+
+	_, err := (&ProtobufDataWriter{}).Write(httptest.NewRecorder(), data)
+	assert.NotNil(t, err)
+}
@@ -15,15 +15,23 @@ import (
 
 // MIME types
 const (
-	JSON = routing.MIME_JSON
-	XML  = routing.MIME_XML
-	XML2 = routing.MIME_XML2
-	HTML = routing.MIME_HTML
+	JSON      = routing.MIME_JSON
+	XML       = routing.MIME_XML
+	XML2      = routing.MIME_XML2
+	HTML      = routing.MIME_HTML
+	YAML      = routing.MIME_YAML
+	YAML2     = routing.MIME_YAML2
+	MSGPACK   = routing.MIME_MSGPACK
+	MSGPACK2  = routing.MIME_MSGPACK2
+	PROTOBUF  = routing.MIME_PROTOBUF
+	PROTOBUF2 = routing.MIME_PROTOBUF2
+	CBOR      = routing.MIME_CBOR
 )
 
 // DataWriters lists all supported content types and the corresponding data writers.
 // By default, JSON, XML, and HTML are supported. You may modify this variable before calling TypeNegotiator
-// to customize supported data writers.
+// to customize supported data writers. The formats package adds the writers for YAML, MessagePack,
+// Protocol Buffers and CBOR.
 var DataWriters = map[string]routing.DataWriter{
 	JSON: &JSONDataWriter{},
 	XML:  &XMLDataWriter{},
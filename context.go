@@ -6,24 +6,36 @@ package routing
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Context represents the contextual data and environment while processing an incoming HTTP request.
+// It implements context.Context by embedding a context derived from the context of the request,
+// so it can be passed to the functions that should stop working when the request is canceled.
 type Context struct {
 	context.Context
+	// Deprecated: Wrote is not maintained. Use Response().Written() instead.
 	Wrote bool
 
 	Request        *http.Request       // the current request
 	ResponseWriter http.ResponseWriter // the response writer
 	router         *Router
+	route          *Route                 // the route matching the current request
 	pnames         []string               // list of route parameter names
 	pvalues        []string               // list of parameter values corresponding to pnames
 	data           map[string]interface{} // data items managed by Get and Set
 	index          int                    // the index of the currently executing handler in handlers
 	handlers       []Handler              // the handlers associated with the current route
 	writer         DataWriter
+	response       *Response // the response wrapper installed by init, returned by Response
+	ownResponse    Response  // the storage of response when the context creates the wrapper
+	maxMemory      int64     // the maximum bytes of a multipart form kept in memory, set by SetUploadLimit
+	maxUploadSize  int64     // the maximum size of a multipart request body, set by SetUploadLimit
+	handlerErr     error     // the error returned by the handlers executed so far, returned by HandlerError
 }
 
 // NewContext creates a new Context object with the given response, request, and the handlers.
@@ -46,6 +58,13 @@ func (c *Context) ParamMap() map[string]string {
 	return paramMap
 }
 
+// Response returns the wrapper of the response writer that tracks the status, the size and the commit state
+// of the response. It returns nil if the context was created without a response writer.
+func (c *Context) Response() *Response {
+	return c.response
+}
+
+// WriteHeader sends the response headers with the given status code.
 func (c *Context) WriteHeader(status int) {
 	c.ResponseWriter.WriteHeader(status)
 }
@@ -82,6 +101,12 @@ func (c *Context) Router() *Router {
 	return c.router
 }
 
+// Route returns the route matching the current request.
+// Nil is returned if no route matches the request, in which case the handlers registered via Router.NotFound are executed.
+func (c *Context) Route() *Route {
+	return c.route
+}
+
 // Param returns the named parameter value that is found in the URL path matching the current route.
 // If the named parameter cannot be found, an empty string will be returned.
 func (c *Context) Param(name string) string {
@@ -93,6 +118,76 @@ func (c *Context) Param(name string) string {
 	return ""
 }
 
+// ParamInt returns the named parameter value as an int.
+// An error is returned if the named parameter cannot be found or its value is not a valid integer.
+func (c *Context) ParamInt(name string) (int, error) {
+	value, err := c.lookupParam(name)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, invalidParamError(name, "int")
+	}
+	return v, nil
+}
+
+// ParamUint returns the named parameter value as a uint.
+// An error is returned if the named parameter cannot be found or its value is not a valid unsigned integer.
+func (c *Context) ParamUint(name string) (uint, error) {
+	value, err := c.lookupParam(name)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseUint(value, 10, 0)
+	if err != nil {
+		return 0, invalidParamError(name, "uint")
+	}
+	return uint(v), nil
+}
+
+// ParamUUID returns the named parameter value as a lowercase UUID string.
+// An error is returned if the named parameter cannot be found or its value is not a valid UUID.
+func (c *Context) ParamUUID(name string) (string, error) {
+	value, err := c.lookupParam(name)
+	if err != nil {
+		return "", err
+	}
+	if len(value) != 36 || matchUUID(value) != 36 {
+		return "", invalidParamError(name, "uuid")
+	}
+	return strings.ToLower(value), nil
+}
+
+// ParamDate returns the named parameter value as a date in the format of "YYYY-MM-DD".
+// An error is returned if the named parameter cannot be found or its value is not a valid date.
+func (c *Context) ParamDate(name string) (time.Time, error) {
+	value, err := c.lookupParam(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	v, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, invalidParamError(name, "date")
+	}
+	return v, nil
+}
+
+// lookupParam returns the named parameter value or an error if the parameter cannot be found.
+func (c *Context) lookupParam(name string) (string, error) {
+	for i, n := range c.pnames {
+		if n == name {
+			return c.pvalues[i], nil
+		}
+	}
+	return "", fmt.Errorf("parameter %q not found", name)
+}
+
+// invalidParamError returns a 400 HTTP error indicating a parameter value is not of the expected type.
+func invalidParamError(name, typ string) HTTPError {
+	return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid %v value for parameter %q", typ, name))
+}
+
 // SetParam sets the named parameter value.
 // This method is primarily provided for writing unit tests.
 func (c *Context) SetParam(name, value string) {
@@ -113,6 +208,7 @@ func (c *Context) Get(name string) interface{} {
 }
 
 // Set stores the named data item in the context so that it can be retrieved later.
+// The item can also be retrieved via Value, using the name as the key.
 func (c *Context) Set(name string, value interface{}) {
 	if c.data == nil {
 		c.data = make(map[string]interface{})
@@ -120,6 +216,60 @@ func (c *Context) Set(name string, value interface{}) {
 	c.data[name] = value
 }
 
+// Value returns the value associated with the key. It implements context.Context so that the data items
+// stored via Set are visible to the code receiving the Context as a context.Context. If the key is not
+// the name of such an item, the value is looked up in the embedded context.
+func (c *Context) Value(key interface{}) interface{} {
+	if name, ok := key.(string); ok {
+		if value, ok := c.data[name]; ok {
+			return value
+		}
+	}
+	if c.Context == nil {
+		return nil
+	}
+	return c.Context.Value(key)
+}
+
+// SetContext replaces the embedded context.Context, and updates the current request to carry the same context.
+// The new context should be derived from the current one, such as c.Context.
+func (c *Context) SetContext(ctx context.Context) {
+	c.Context = ctx
+	if c.Request != nil {
+		c.Request = c.Request.WithContext(ctx)
+	}
+}
+
+// WithValue associates the value with the key in the embedded context.Context and in the context of the current request.
+// Unlike Set, the value is also visible to the code that only has access to the request.
+func (c *Context) WithValue(key, value interface{}) {
+	c.SetContext(context.WithValue(c.Context, key, value))
+}
+
+// WithCancel makes the embedded context.Context and the context of the current request cancelable.
+// The returned function cancels the context and should be called when the handler finishes using it.
+func (c *Context) WithCancel() context.CancelFunc {
+	ctx, cancel := context.WithCancel(c.Context)
+	c.SetContext(ctx)
+	return cancel
+}
+
+// WithTimeout sets a timeout for the embedded context.Context and the context of the current request.
+// The returned function cancels the context and should be called when the handler finishes using it.
+func (c *Context) WithTimeout(timeout time.Duration) context.CancelFunc {
+	ctx, cancel := context.WithTimeout(c.Context, timeout)
+	c.SetContext(ctx)
+	return cancel
+}
+
+// WithDeadline sets a deadline for the embedded context.Context and the context of the current request.
+// The returned function cancels the context and should be called when the handler finishes using it.
+func (c *Context) WithDeadline(deadline time.Time) context.CancelFunc {
+	ctx, cancel := context.WithDeadline(c.Context, deadline)
+	c.SetContext(ctx)
+	return cancel
+}
+
 // Query returns the first value for the named component of the URL query parameters.
 // If key is not present, it returns the specified default value or an empty string.
 func (c *Context) Query(name string, defaultValue ...string) string {
@@ -138,7 +288,7 @@ func (c *Context) Query(name string, defaultValue ...string) string {
 // If key is not present, it returns the specified default value or an empty string.
 func (c *Context) Form(key string, defaultValue ...string) string {
 	r := c.Request
-	r.ParseMultipartForm(32 << 20)
+	c.parseMultipartForm()
 	if vs := r.Form[key]; len(vs) > 0 {
 		return vs[0]
 	}
@@ -153,7 +303,7 @@ func (c *Context) Form(key string, defaultValue ...string) string {
 // If key is not present, it returns the specified default value or an empty string.
 func (c *Context) PostForm(key string, defaultValue ...string) string {
 	r := c.Request
-	r.ParseMultipartForm(32 << 20)
+	c.parseMultipartForm()
 	if vs := r.PostForm[key]; len(vs) > 0 {
 		return vs[0]
 	}
@@ -178,6 +328,28 @@ func (c *Context) Next() (err error) {
 	return
 }
 
+// HandlerError returns the error returned by the handlers of the current request executed so far.
+// Posterior handlers can use it to get the error of the route handlers, and shutdown handlers
+// the error of all other handlers. It returns nil if the handlers succeed.
+func (c *Context) HandlerError() error {
+	return c.handlerErr
+}
+
+// runAll executes all the given handlers, even if some of them return an error, and returns the error
+// combining the given error and those returned by the handlers. A handler may still call Next to execute
+// the rest of the handlers itself, or Abort to skip them.
+func (c *Context) runAll(handlers []Handler, err error) error {
+	c.handlers = handlers
+	c.handlerErr = err
+	for c.index = 0; c.index < len(handlers); c.index++ {
+		if e := handlers[c.index](c); e != nil {
+			err = combineErrors(err, e)
+			c.handlerErr = err
+		}
+	}
+	return err
+}
+
 // Abort skips the rest of the handlers associated with the current route.
 // Abort is normally used when a handler handles the request normally and wants to skip the rest of the handlers.
 // If a handler wants to indicate an error condition, it should simply return the error without calling Abort.
@@ -191,7 +363,7 @@ func (c *Context) Abort() {
 // Parameter values will be properly URL encoded.
 // The method returns an empty string if the URL creation fails.
 func (c *Context) URL(route string, pairs ...interface{}) string {
-	if r := c.router.namedRoutes[route]; r != nil {
+	if r := c.router.Route(route); r != nil {
 		return r.URL(pairs...)
 	}
 	return ""
@@ -202,9 +374,25 @@ func (c *Context) URL(route string, pairs ...interface{}) string {
 // and find a matching reader from DataReaders to read the request data.
 // If there is no match or if the request is a GET request, it will use DefaultFormDataReader
 // to read the request data.
+// The populated data is then validated using DefaultValidator. By default, struct fields are validated
+// according to their "validate" tags, and ValidationErrors is returned if any field is invalid.
 func (c *Context) Read(data interface{}) error {
+	if err := c.read(data); err != nil {
+		return err
+	}
+	return validate(data)
+}
+
+// read populates the given struct variable with the data from the current request without validating it.
+func (c *Context) read(data interface{}) error {
 	if c.Request.Method != "GET" {
 		t := getContentType(c.Request)
+		if t == MIME_MULTIPART_FORM {
+			// parse the form according to the upload limits before the data reader does it
+			if err := c.parseMultipartForm(); err != nil {
+				return err
+			}
+		}
 		if reader, ok := DataReaders[t]; ok {
 			return reader.Read(c.Request, data)
 		}
@@ -229,13 +417,31 @@ func (c *Context) SetDataWriter(writer DataWriter) {
 
 // init sets the request and response of the context and resets all other properties.
 func (c *Context) init(responseWriter http.ResponseWriter, request *http.Request) {
+	if res, ok := responseWriter.(*Response); ok {
+		// share the commit state with the router that passes the request to this one
+		c.response = res
+	} else if responseWriter != nil {
+		c.ownResponse.reset(responseWriter)
+		c.response = &c.ownResponse
+		responseWriter = c.response
+	} else {
+		c.response = nil
+	}
 	c.ResponseWriter = responseWriter
 	c.Request = request
+	c.route = nil
 	c.data = nil
+	c.maxMemory, c.maxUploadSize = 0, 0
+	c.handlerErr = nil
 	c.index = -1
 	c.writer = DefaultDataWriter
 
-	c.Context = context.Background()
+	// derive from the request context so that handlers observe client disconnects and server shutdowns
+	if request != nil {
+		c.Context = request.Context()
+	} else {
+		c.Context = context.Background()
+	}
 }
 
 func getContentType(req *http.Request) string {
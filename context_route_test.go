@@ -0,0 +1,39 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextRoute(t *testing.T) {
+	r := New()
+	r.AutoHead = true
+	var route *Route
+	users := r.Get("/users/<id>", func(c *Context) error {
+		route = c.Route()
+		return nil
+	})
+	r.NotFound(func(c *Context) error {
+		route = c.Route()
+		return nil
+	})
+
+	for _, method := range []string{"GET", "HEAD"} {
+		route = nil
+		req, _ := http.NewRequest(method, "/users/1", nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t, users, route, method)
+	}
+
+	route = users
+	req, _ := http.NewRequest("GET", "/items", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Nil(t, route)
+}
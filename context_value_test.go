@@ -0,0 +1,73 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextRequestContext(t *testing.T) {
+	type contextTestKey string
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), contextTestKey("a"), "x"))
+	req, _ := http.NewRequest("GET", "/users", nil)
+	req = req.WithContext(ctx)
+
+	var (
+		value interface{}
+		err   error
+	)
+	r := New()
+	r.Get("/users", func(c *Context) error {
+		value = c.Value(contextTestKey("a"))
+		cancel()
+		<-c.Done()
+		err = c.Err()
+		return nil
+	})
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "x", value)
+	assert.Equal(t, context.Canceled, err)
+
+	c := NewContext(nil, nil)
+	assert.Nil(t, c.Err())
+}
+
+func TestContextValue(t *testing.T) {
+	type contextTestKey string
+	req, _ := http.NewRequest("GET", "/users", nil)
+	c := NewContext(nil, req)
+	c.Set("user", "john")
+	assert.Equal(t, "john", c.Value("user"))
+	assert.Nil(t, c.Value("missing"))
+
+	c.WithValue(contextTestKey("tenant"), "acme")
+	assert.Equal(t, "acme", c.Value(contextTestKey("tenant")))
+	assert.Equal(t, "acme", c.Request.Context().Value(contextTestKey("tenant")))
+	assert.Equal(t, "john", c.Value("user"))
+
+	cancel := c.WithTimeout(time.Hour)
+	deadline, ok := c.Request.Context().Deadline()
+	assert.True(t, ok)
+	assert.Equal(t, deadline, func() time.Time { d, _ := c.Deadline(); return d }())
+	cancel()
+	assert.Equal(t, context.Canceled, c.Request.Context().Err())
+
+	c = NewContext(nil, req)
+	cancel = c.WithDeadline(time.Now().Add(-time.Second))
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, c.Err())
+
+	c = NewContext(nil, req)
+	cancel = c.WithCancel()
+	cancel()
+	assert.Equal(t, context.Canceled, c.Err())
+	assert.Nil(t, req.Context().Err())
+}
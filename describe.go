@@ -0,0 +1,136 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+)
+
+type (
+	// RouteInfo describes a route registered with a router.
+	RouteInfo struct {
+		Method   string        `json:"method"`
+		Host     string        `json:"host,omitempty"`
+		Pattern  string        `json:"pattern"`
+		Prefix   string        `json:"prefix,omitempty"`
+		Name     string        `json:"name,omitempty"`
+		Tags     []interface{} `json:"tags,omitempty"`
+		Params   []ParamInfo   `json:"params,omitempty"`
+		Handlers []string      `json:"handlers"`
+	}
+
+	// ParamInfo describes a parameter of a route.
+	ParamInfo struct {
+		Name       string `json:"name"`
+		Constraint string `json:"constraint,omitempty"` // the type or regular expression of the parameter, empty if it matches any non-slash characters
+	}
+
+	// RouteInfos is a list of route descriptions that can be rendered as text, JSON or Markdown.
+	RouteInfos []RouteInfo
+)
+
+// Describe returns the descriptions of all routes managed by the router, in the order they were added.
+// Handler names are obtained from the runtime, and include the handlers of the route group
+// and the route lifecycle handlers. The routes of the routers mounted via RouteGroup.Mount are not included.
+func (r *Router) Describe() RouteInfos {
+	routes := r.Routes()
+	infos := make(RouteInfos, len(routes))
+	for i, route := range routes {
+		infos[i] = describeRoute(route)
+	}
+	return infos
+}
+
+// describeRoute returns the description of the route.
+func describeRoute(route *Route) RouteInfo {
+	handlers := route.HandlerChain()
+	info := RouteInfo{
+		Method:   route.method,
+		Host:     route.group.host,
+		Pattern:  route.Path(),
+		Prefix:   route.group.prefix,
+		Name:     route.name,
+		Tags:     route.tags,
+		Handlers: make([]string, len(handlers)),
+	}
+	for _, e := range parsePattern(route.group.host + route.Path()) {
+		if e.param {
+			info.Params = append(info.Params, ParamInfo{e.name, e.pattern})
+		}
+	}
+	for i, handler := range handlers {
+		info.Handlers[i] = handlerName(handler)
+	}
+	return info
+}
+
+// handlerName returns the name of the function implementing the handler.
+func handlerName(handler Handler) string {
+	if f := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()); f != nil {
+		return f.Name()
+	}
+	return "unknown"
+}
+
+// Text renders the routes as a table of aligned columns listing the method, host, pattern, name and handlers of each route.
+func (ri RouteInfos) Text() string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tHOST\tPATTERN\tNAME\tHANDLERS")
+	for _, info := range ri {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", info.Method, info.Host, info.Pattern, info.Name, strings.Join(info.Handlers, ", "))
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// JSON renders the routes as an indented JSON array.
+func (ri RouteInfos) JSON() ([]byte, error) {
+	return json.MarshalIndent(ri, "", "  ")
+}
+
+// Markdown renders the routes as a Markdown table.
+func (ri RouteInfos) Markdown() string {
+	var buf bytes.Buffer
+	buf.WriteString("| Method | Host | Pattern | Name | Params | Tags | Handlers |\n")
+	buf.WriteString("|--------|------|---------|------|--------|------|----------|\n")
+	for _, info := range ri {
+		params := make([]string, len(info.Params))
+		for i, p := range info.Params {
+			params[i] = p.Name
+			if p.Constraint != "" {
+				params[i] += ":" + p.Constraint
+			}
+		}
+		tags := make([]string, len(info.Tags))
+		for i, tag := range info.Tags {
+			tags[i] = fmt.Sprint(tag)
+		}
+		fmt.Fprintf(&buf, "| %v | %v | %v | %v | %v | %v | %v |\n",
+			info.Method,
+			markdownCell(info.Host),
+			markdownCell(info.Pattern),
+			markdownCell(info.Name),
+			markdownCell(strings.Join(params, ", ")),
+			markdownCell(strings.Join(tags, ", ")),
+			markdownCell(strings.Join(info.Handlers, ", ")))
+	}
+	return buf.String()
+}
+
+// markdownCell escapes the text so that it can be used in a Markdown table cell.
+func markdownCell(s string) string {
+	if s == "" {
+		return ""
+	}
+	s = strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+	return "`" + s + "`"
+}
@@ -0,0 +1,66 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func describeHandler(c *Context) error {
+	return nil
+}
+
+func TestRouterDescribe(t *testing.T) {
+	r := New()
+	r.Get("/users/<id:int>", describeHandler).Name("user").Tag("public")
+	api := r.Host("<tenant>.example.com").Group("/api")
+	api.Post("/items/<name>/<code:\\d+>", describeHandler)
+
+	infos := r.Describe()
+	if assert.Len(t, infos, 2) {
+		assert.Equal(t, RouteInfo{
+			Method:   "GET",
+			Pattern:  "/users/<id:int>",
+			Name:     "user",
+			Tags:     []interface{}{"public"},
+			Params:   []ParamInfo{{"id", "int"}},
+			Handlers: []string{"github.com/ltick/tick-routing.describeHandler"},
+		}, infos[0])
+		assert.Equal(t, RouteInfo{
+			Method:   "POST",
+			Host:     "<tenant>.example.com",
+			Pattern:  "/api/items/<name>/<code:\\d+>",
+			Prefix:   "/api",
+			Params:   []ParamInfo{{"tenant", ""}, {"name", ""}, {"code", "\\d+"}},
+			Handlers: []string{"github.com/ltick/tick-routing.describeHandler"},
+		}, infos[1])
+	}
+
+	text := infos.Text()
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if assert.Len(t, lines, 3) {
+		assert.True(t, strings.HasPrefix(lines[0], "METHOD  HOST"))
+		assert.Contains(t, lines[1], "/users/<id:int>")
+		assert.Contains(t, lines[2], "<tenant>.example.com")
+	}
+
+	data, err := infos.JSON()
+	assert.Nil(t, err)
+	var decoded []map[string]interface{}
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	if assert.Len(t, decoded, 2) {
+		assert.Equal(t, "user", decoded[0]["name"])
+		assert.Nil(t, decoded[1]["name"])
+	}
+
+	md := infos.Markdown()
+	assert.Contains(t, md, "| Method | Host | Pattern | Name | Params | Tags | Handlers |\n")
+	assert.Contains(t, md, "| GET |  | `/users/<id:int>` | `user` | `id:int` | `public` | `github.com/ltick/tick-routing.describeHandler` |\n")
+	assert.Contains(t, md, "`tenant, name, code:\\d+`")
+}
@@ -4,7 +4,15 @@
 
 package routing
 
-import "net/http"
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+)
 
 // HTTPError represents an HTTP error with HTTP status code and error message
 type HTTPError interface {
@@ -38,3 +46,320 @@ func (e *httpError) Error() string {
 func (e *httpError) StatusCode() int {
 	return e.Status
 }
+
+// statusError associates an HTTP status code with an error. It is created by WithStatus.
+type statusError struct {
+	err    error
+	status int
+}
+
+// WithStatus wraps the error with the given HTTP status code. The returned error implements HTTPError
+// and has the same message as the wrapped error, which can be retrieved via errors.Unwrap, errors.Is and errors.As.
+// Nil is returned if the error is nil. For example,
+//
+//	if err == sql.ErrNoRows {
+//		return routing.WithStatus(err, http.StatusNotFound)
+//	}
+func WithStatus(err error, status int) HTTPError {
+	if err == nil {
+		return nil
+	}
+	return &statusError{err, status}
+}
+
+// Error returns the message of the wrapped error.
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+// StatusCode returns the HTTP status code.
+func (e *statusError) StatusCode() int {
+	return e.status
+}
+
+// Unwrap returns the wrapped error.
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// errorStatus maps an error to an HTTP status code. It is registered via Router.MapError.
+type errorStatus struct {
+	err    error
+	status int
+}
+
+// MapError registers the HTTP status code to be used for the errors that match the target error
+// according to errors.Is, such as sql.ErrNoRows or an error wrapping it. The mapping only applies to errors
+// that do not implement or wrap an HTTPError. Mappings registered earlier take precedence. For example,
+//
+//	router.MapError(sql.ErrNoRows, http.StatusNotFound)
+//	router.MapError(context.DeadlineExceeded, http.StatusGatewayTimeout)
+//
+// MapError can be called while the router is serving requests.
+func (r *Router) MapError(target error, status int) *Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	mappings, _ := r.errorStatuses.Load().([]errorStatus)
+	r.errorStatuses.Store(append(mappings[:len(mappings):len(mappings)], errorStatus{target, status}))
+	return r
+}
+
+// ErrorStatus returns the HTTP status code to respond with for the error.
+// If the error implements or wraps an HTTPError (as determined by errors.As), its status code is returned.
+// Otherwise, the status code registered via MapError for the first matching error is returned,
+// or http.StatusInternalServerError if there is none.
+// The status code of HandlerErrors is determined by its first error in the same way.
+func (r *Router) ErrorStatus(err error) int {
+	err = primaryError(err)
+	var httpError HTTPError
+	if errors.As(err, &httpError) {
+		return httpError.StatusCode()
+	}
+	mappings, _ := r.errorStatuses.Load().([]errorStatus)
+	for _, es := range mappings {
+		if errors.Is(err, es.err) {
+			return es.status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// primaryError returns the first of the combined errors if the error is HandlerErrors, or the error itself otherwise.
+func primaryError(err error) error {
+	for {
+		es, ok := err.(HandlerErrors)
+		if !ok || len(es) == 0 {
+			return err
+		}
+		err = es[0]
+	}
+}
+
+// ErrorStatus returns the HTTP status code to respond with for the error, as determined by Router.ErrorStatus.
+// If the context is not associated with a router, only the HTTPError implemented or wrapped by the error is considered.
+func (c *Context) ErrorStatus(err error) int {
+	if c.router != nil {
+		return c.router.ErrorStatus(err)
+	}
+	var httpError HTTPError
+	if errors.As(err, &httpError) {
+		return httpError.StatusCode()
+	}
+	return http.StatusInternalServerError
+}
+
+// Problem represents an HTTP error carrying the problem details defined by RFC 7807.
+// Router.handleError and fault.ErrorHandler write a Problem as "application/problem+json",
+// or as "application/problem+xml" if the data writer of the request writes XML. See Context.WriteProblem.
+type Problem struct {
+	Type     string // a URI reference identifying the problem type, "about:blank" if empty
+	Title    string // a short summary of the problem type
+	Status   int    // the HTTP status code
+	Detail   string // an explanation specific to this occurrence of the problem
+	Instance string // a URI reference identifying this occurrence of the problem
+	// Extensions holds additional members of the problem details. The members cannot override the standard ones.
+	Extensions map[string]interface{}
+}
+
+// NewProblem creates a Problem with the given HTTP status code and an optional detail message.
+// The title is set as the result of http.StatusText().
+func NewProblem(status int, detail ...string) *Problem {
+	p := &Problem{
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+	if len(detail) > 0 {
+		p.Detail = detail[0]
+	}
+	return p
+}
+
+// WithType sets the problem type URI and the title, and returns the problem itself.
+func (p *Problem) WithType(typ, title string) *Problem {
+	p.Type, p.Title = typ, title
+	return p
+}
+
+// WithInstance sets the URI identifying the occurrence of the problem, and returns the problem itself.
+func (p *Problem) WithInstance(instance string) *Problem {
+	p.Instance = instance
+	return p
+}
+
+// With sets an extension member of the problem details, and returns the problem itself.
+func (p *Problem) With(name string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]interface{})
+	}
+	p.Extensions[name] = value
+	return p
+}
+
+// Error returns the detail message, or the title if there is no detail message.
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+	return p.Title
+}
+
+// StatusCode returns the HTTP status code.
+func (p *Problem) StatusCode() int {
+	return p.Status
+}
+
+// members returns the members of the problem details in the order defined by RFC 7807, followed by
+// the extension members sorted by name. Empty standard members are omitted.
+func (p *Problem) members() (names []string, values []interface{}) {
+	add := func(name string, value interface{}, empty bool) {
+		if !empty {
+			names = append(names, name)
+			values = append(values, value)
+		}
+	}
+	add("type", p.Type, p.Type == "")
+	add("title", p.Title, p.Title == "")
+	add("status", p.Status, p.Status == 0)
+	add("detail", p.Detail, p.Detail == "")
+	add("instance", p.Instance, p.Instance == "")
+	extensions := make([]string, 0, len(p.Extensions))
+	for name := range p.Extensions {
+		extensions = append(extensions, name)
+	}
+	sort.Strings(extensions)
+	for _, name := range extensions {
+		switch name {
+		case "type", "title", "status", "detail", "instance":
+			continue
+		}
+		add(name, p.Extensions[name], false)
+	}
+	return
+}
+
+// MarshalJSON encodes the problem details as a JSON object with the extension members at the top level.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	names, values := p.members()
+	buf := []byte{'{'}
+	for i, name := range names {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		key, _ := json.Marshal(name)
+		value, err := json.Marshal(values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf = append(append(append(buf, key...), ':'), value...)
+	}
+	return append(buf, '}'), nil
+}
+
+// MarshalXML encodes the problem details as a "problem" element in the "urn:ietf:rfc:7807" namespace,
+// with a child element for each member.
+func (p *Problem) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{Name: xml.Name{Space: "urn:ietf:rfc:7807", Local: "problem"}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	names, values := p.members()
+	for i, name := range names {
+		if err := e.EncodeElement(values[i], xml.StartElement{Name: xml.Name{Local: name}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// WriteProblem writes the problem details to the response with the status code of the problem.
+// The problem is written as "application/problem+xml" if the data writer set via SetDataWriter writes XML,
+// and as "application/problem+json" otherwise.
+func (c *Context) WriteProblem(p *Problem) error {
+	var (
+		data []byte
+		err  error
+	)
+	header := c.ResponseWriter.Header()
+	if c.writesXML() {
+		header.Set("Content-Type", MIME_PROBLEM_XML+"; charset=UTF-8")
+		if data, err = xml.Marshal(p); err == nil {
+			data = append([]byte(xml.Header), data...)
+		}
+	} else {
+		header.Set("Content-Type", MIME_PROBLEM_JSON)
+		data, err = json.Marshal(p)
+	}
+	if err != nil {
+		return err
+	}
+	header.Del("Content-Length")
+	header.Set("X-Content-Type-Options", "nosniff")
+	status := p.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	c.ResponseWriter.WriteHeader(status)
+	_, err = c.ResponseWriter.Write(data)
+	return err
+}
+
+// writesXML returns whether the data writer of the context writes XML, according to the Content-Type header it sets.
+func (c *Context) writesXML() bool {
+	header := make(headerRecorder)
+	c.writer.SetHeader(header)
+	mediaType, _, _ := mime.ParseMediaType(http.Header(header).Get("Content-Type"))
+	return mediaType == MIME_XML || mediaType == MIME_XML2 || strings.HasSuffix(mediaType, "+xml")
+}
+
+// headerRecorder is an http.ResponseWriter that only records the headers set to it.
+type headerRecorder http.Header
+
+func (h headerRecorder) Header() http.Header         { return http.Header(h) }
+func (h headerRecorder) Write(p []byte) (int, error) { return len(p), nil }
+func (h headerRecorder) WriteHeader(int)             {}
+
+// HandlerErrors combines the errors returned by multiple handlers of a request, such as a route handler
+// and a shutdown handler. It implements HTTPError with the HTTP status code of the first error.
+type HandlerErrors []error
+
+// Error returns the error messages separated by semicolons.
+// The text of http.StatusInternalServerError is returned if there is no error.
+func (es HandlerErrors) Error() string {
+	if len(es) == 0 {
+		return http.StatusText(http.StatusInternalServerError)
+	}
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// StatusCode returns the HTTP status code of the first error, or http.StatusInternalServerError
+// if there is no error or the first error does not implement or wrap an HTTPError. Router.ErrorStatus
+// also considers the mappings registered via Router.MapError for the first error.
+func (es HandlerErrors) StatusCode() int {
+	var httpError HTTPError
+	if len(es) > 0 && errors.As(es[0], &httpError) {
+		return httpError.StatusCode()
+	}
+	return http.StatusInternalServerError
+}
+
+// Unwrap returns the combined errors. It allows errors.Is and errors.As to check each of them.
+func (es HandlerErrors) Unwrap() []error {
+	return es
+}
+
+// combineErrors returns an error combining the two errors, either of which may be nil.
+func combineErrors(err1, err2 error) error {
+	if err1 == nil {
+		return err2
+	} else if err2 == nil {
+		return err1
+	}
+	if es, ok := err1.(HandlerErrors); ok {
+		return append(es[:len(es):len(es)], err2)
+	}
+	return HandlerErrors{err1, err2}
+}
@@ -6,7 +6,11 @@ package routing
 
 import (
 	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -24,3 +28,157 @@ func TestNewHttpError(t *testing.T) {
 	s, _ := json.Marshal(e)
 	assert.Equal(t, `{"status":404,"message":"abc"}`, string(s))
 }
+
+type xmlDataWriter struct{}
+
+func (w *xmlDataWriter) SetHeader(res http.ResponseWriter) {
+	res.Header().Set("Content-Type", "application/xml; charset=UTF-8")
+}
+
+func (w *xmlDataWriter) Write(res http.ResponseWriter, data interface{}) (int, error) {
+	return 0, nil
+}
+
+func TestProblem(t *testing.T) {
+	p := NewProblem(http.StatusForbidden)
+	assert.Equal(t, http.StatusForbidden, p.StatusCode())
+	assert.Equal(t, "Forbidden", p.Error())
+	s, _ := json.Marshal(p)
+	assert.Equal(t, `{"title":"Forbidden","status":403}`, string(s))
+
+	p = NewProblem(http.StatusForbidden, "Your current balance is 30, but that costs 50.").
+		WithType("https://example.com/probs/out-of-credit", "You do not have enough credit.").
+		WithInstance("/account/12345/msgs/abc").
+		With("balance", 30).
+		With("accounts", []string{"/account/12345", "/account/67890"}).
+		With("status", 200)
+	assert.Equal(t, "Your current balance is 30, but that costs 50.", p.Error())
+	s, _ = json.Marshal(p)
+	assert.Equal(t, `{"type":"https://example.com/probs/out-of-credit","title":"You do not have enough credit.","status":403,"detail":"Your current balance is 30, but that costs 50.","instance":"/account/12345/msgs/abc","accounts":["/account/12345","/account/67890"],"balance":30}`, string(s))
+	s, _ = xml.Marshal(p)
+	assert.Equal(t, `<problem xmlns="urn:ietf:rfc:7807"><type>https://example.com/probs/out-of-credit</type><title>You do not have enough credit.</title><status>403</status><detail>Your current balance is 30, but that costs 50.</detail><instance>/account/12345/msgs/abc</instance><accounts>/account/12345</accounts><accounts>/account/67890</accounts><balance>30</balance></problem>`, string(s))
+}
+
+func TestContextWriteProblem(t *testing.T) {
+	res := httptest.NewRecorder()
+	c := NewContext(res, nil)
+	assert.Nil(t, c.WriteProblem(NewProblem(http.StatusNotFound, "no such user")))
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, MIME_PROBLEM_JSON, res.Header().Get("Content-Type"))
+	assert.Equal(t, `{"title":"Not Found","status":404,"detail":"no such user"}`, res.Body.String())
+
+	res = httptest.NewRecorder()
+	c = NewContext(res, nil)
+	c.SetDataWriter(&xmlDataWriter{})
+	assert.Nil(t, c.WriteProblem(NewProblem(http.StatusNotFound)))
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, MIME_PROBLEM_XML+"; charset=UTF-8", res.Header().Get("Content-Type"))
+	assert.Equal(t, xml.Header+`<problem xmlns="urn:ietf:rfc:7807"><title>Not Found</title><status>404</status></problem>`, res.Body.String())
+
+	// Router.handleError writes problems in the negotiated format
+	r := New()
+	r.Get("/users/<id>", func(c *Context) error {
+		c.SetDataWriter(&xmlDataWriter{})
+		return NewProblem(http.StatusNotFound).WithInstance(c.Request.URL.Path)
+	})
+	res = httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/1", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, MIME_PROBLEM_XML+"; charset=UTF-8", res.Header().Get("Content-Type"))
+	assert.Contains(t, res.Body.String(), "<instance>/users/1</instance>")
+}
+
+func TestWithStatus(t *testing.T) {
+	assert.Nil(t, WithStatus(nil, http.StatusNotFound))
+
+	cause := errors.New("no rows")
+	e := WithStatus(cause, http.StatusNotFound)
+	assert.Equal(t, http.StatusNotFound, e.StatusCode())
+	assert.Equal(t, "no rows", e.Error())
+	assert.True(t, errors.Is(e, cause))
+	assert.Equal(t, cause, errors.Unwrap(e))
+}
+
+func TestHandlerErrors(t *testing.T) {
+	es := HandlerErrors{NewHTTPError(http.StatusNotFound), errors.New("abc")}
+	assert.Equal(t, http.StatusNotFound, es.StatusCode())
+	assert.Equal(t, "Not Found; abc", es.Error())
+
+	es = HandlerErrors{errors.New("abc")}
+	assert.Equal(t, http.StatusInternalServerError, es.StatusCode())
+
+	es = HandlerErrors{}
+	assert.Equal(t, http.StatusInternalServerError, es.StatusCode())
+	assert.Equal(t, http.StatusText(http.StatusInternalServerError), es.Error())
+	assert.Equal(t, http.StatusInternalServerError, New().ErrorStatus(es))
+}
+
+func TestErrorStatus(t *testing.T) {
+	errNoRows := errors.New("no rows")
+	errTimeout := errors.New("timeout")
+	r := New()
+	r.MapError(errNoRows, http.StatusNotFound).MapError(errTimeout, http.StatusGatewayTimeout)
+
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{errors.New("abc"), http.StatusInternalServerError},
+		{NewHTTPError(http.StatusBadRequest), http.StatusBadRequest},
+		{fmt.Errorf("loading user: %w", NewHTTPError(http.StatusForbidden)), http.StatusForbidden},
+		{fmt.Errorf("loading user: %w", WithStatus(errNoRows, http.StatusGone)), http.StatusGone},
+		{errNoRows, http.StatusNotFound},
+		{fmt.Errorf("loading user: %w", errTimeout), http.StatusGatewayTimeout},
+		{HandlerErrors{fmt.Errorf("wrapped: %w", NewHTTPError(http.StatusConflict)), errNoRows}, http.StatusConflict},
+		{HandlerErrors{errNoRows, NewHTTPError(http.StatusBadRequest)}, http.StatusNotFound},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.status, r.ErrorStatus(test.err), test.err.Error())
+	}
+	assert.Equal(t, http.StatusInternalServerError, NewContext(nil, nil).ErrorStatus(errNoRows))
+	assert.Equal(t, http.StatusForbidden, NewContext(nil, nil).ErrorStatus(tests[2].err))
+
+	r.Get("/users/<id>", func(c *Context) error {
+		return fmt.Errorf("loading user %v: %w", c.Param("id"), errNoRows)
+	})
+	r.Get("/accounts/<id>", func(c *Context) error {
+		return fmt.Errorf("loading account: %w", NewProblem(http.StatusForbidden))
+	})
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/1", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, "loading user 1: no rows\n", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/accounts/1", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusForbidden, res.Code)
+	assert.Equal(t, MIME_PROBLEM_JSON, res.Header().Get("Content-Type"))
+
+	// the mapping applies to the first of the combined errors
+	r.Get("/orders/<id>", func(c *Context) error {
+		return errNoRows
+	}).AppendShutdownHandler(func(c *Context) error {
+		return errors.New("cleanup failed")
+	})
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/orders/1", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+
+	// mappings can be registered while serving requests
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 100; i++ {
+			r.MapError(fmt.Errorf("error %v", i), http.StatusTeapot)
+		}
+		close(done)
+	}()
+	for i := 0; i < 100; i++ {
+		r.ErrorStatus(errTimeout)
+	}
+	<-done
+	assert.Equal(t, http.StatusGatewayTimeout, r.ErrorStatus(errTimeout))
+}
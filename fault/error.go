@@ -6,15 +6,15 @@
 package fault
 
 import (
-	"net/http"
+	"errors"
 
 	"github.com/ltick/tick-routing"
 )
 
 // ErrorHandler returns a handler that handles errors returned by the handlers following this one.
-// If the error implements routing.HTTPError, the handler will set the HTTP status code accordingly.
-// Otherwise the HTTP status is set as http.StatusInternalServerError. The handler will also write the error
-// as the response body.
+// If the error implements or wraps a routing.HTTPError, the handler will set the HTTP status code accordingly.
+// Otherwise the HTTP status is set as the status code mapped to the error via routing.Router.MapError,
+// or http.StatusInternalServerError. The handler will also write the error as the response body.
 //
 // A log function can be provided to log a message whenever an error is handled. If nil, no message will be logged.
 //
@@ -54,14 +54,22 @@ func ErrorHandler(logf LogFunc, errorf ...ConvertErrorFunc) routing.Handler {
 	}
 }
 
-// writeError writes the error to the response.
-// If the error implements HTTPError, it will set the HTTP status as the result of the StatusCode() call of the error.
-// Otherwise, the HTTP status will be set as http.StatusInternalServerError.
+// writeError writes the error to the response unless the response has already been written.
+// A routing.Problem, or an error wrapping one, is written via routing.Context.WriteProblem in the format
+// of the data writer of the context.
+// Otherwise, the HTTP status is determined by routing.Context.ErrorStatus: the status code of the HTTPError
+// implemented or wrapped by the error, the status code mapped to the error via routing.Router.MapError,
+// or http.StatusInternalServerError.
 func writeError(c *routing.Context, err error) {
-	if httpError, ok := err.(routing.HTTPError); ok {
-		c.ResponseWriter.WriteHeader(httpError.StatusCode())
-	} else {
-		c.ResponseWriter.WriteHeader(http.StatusInternalServerError)
+	if res := c.Response(); res != nil && res.Written() {
+		// the response has been started and can no longer report the error
+		return
 	}
+	var problem *routing.Problem
+	if errors.As(err, &problem) {
+		c.WriteProblem(problem)
+		return
+	}
+	c.ResponseWriter.WriteHeader(c.ErrorStatus(err))
 	c.Write(err)
 }
@@ -3,6 +3,7 @@ package fault
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -67,6 +68,28 @@ func Test_writeError(t *testing.T) {
 	writeError(c, routing.NewHTTPError(http.StatusNotFound, "xyz"))
 	assert.Equal(t, http.StatusNotFound, res.Code)
 	assert.Equal(t, "xyz", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/", nil)
+	c = routing.NewContext(res, req)
+	writeError(c, routing.NewProblem(http.StatusConflict, "xyz"))
+	assert.Equal(t, http.StatusConflict, res.Code)
+	assert.Equal(t, routing.MIME_PROBLEM_JSON, res.Header().Get("Content-Type"))
+	assert.Equal(t, `{"title":"Conflict","status":409,"detail":"xyz"}`, res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/", nil)
+	c = routing.NewContext(res, req)
+	writeError(c, fmt.Errorf("loading user: %w", routing.NewHTTPError(http.StatusNotFound)))
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, "loading user: Not Found", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/", nil)
+	c = routing.NewContext(res, req)
+	writeError(c, routing.WithStatus(errors.New("abc"), http.StatusTeapot))
+	assert.Equal(t, http.StatusTeapot, res.Code)
+	assert.Equal(t, "abc", res.Body.String())
 }
 
 func convertError(c *routing.Context, err error) error {
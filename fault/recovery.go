@@ -21,9 +21,9 @@ type (
 // Recovery can be considered as a combination of ErrorHandler and PanicHandler.
 //
 // The handler will recover from panics and render the recovered error or the error returned by a handler.
-// If the error implements routing.HTTPError, the handler will set the HTTP status code accordingly.
-// Otherwise the HTTP status is set as http.StatusInternalServerError. The handler will also write the error
-// as the response body.
+// If the error implements or wraps a routing.HTTPError, the handler will set the HTTP status code accordingly.
+// Otherwise the HTTP status is set as the status code mapped to the error via routing.Router.MapError,
+// or http.StatusInternalServerError. The handler will also write the error as the response body.
 //
 // A log function can be provided to log a message whenever an error is handled. If nil, no message will be logged.
 //
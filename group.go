@@ -6,23 +6,35 @@ package routing
 
 import (
 	"strings"
+	"sync/atomic"
 )
 
 // RouteGroup represents a group of routes that share the same path prefix.
 type RouteGroup struct {
+	host     string // the host pattern that the routes in this group should match, empty for any host
 	prefix   string
 	router   *Router
+	parent   *RouteGroup   // the group this group is created from, nil for the group of the router
+	children []*RouteGroup // the groups created from this group
 	handlers []Handler
 	// 以下预处理Handler
 	startupHandlers   []Handler
 	anteriorHandlers  []Handler
 	posteriorHandlers []Handler
 	shutdownHandlers  []Handler
+	notFound          []Handler             // the not-found handlers set via NotFound, nil if not set
+	errorHandler      func(*Context, error) // the error handler set via OnError, nil if not set
+	lifecycle         atomic.Value          // the resolved *lifecycle, replaced as a whole when lifecycle handlers are appended
+}
+
+// lifecycle holds the lifecycle handlers of a route group, each preceded by those of its parent groups.
+type lifecycle struct {
+	startup, anterior, posterior, shutdown []Handler
 }
 
 // newRouteGroup creates a new RouteGroup with the given path prefix, router, and handlers.
 func newRouteGroup(prefix string, router *Router, handlers []Handler, startupHandlers []Handler, anteriorHandlers []Handler, posteriorHandlers []Handler, shutdownHandlers []Handler) *RouteGroup {
-	return &RouteGroup{
+	rg := &RouteGroup{
 		prefix:            prefix,
 		router:            router,
 		handlers:          handlers,
@@ -31,22 +43,69 @@ func newRouteGroup(prefix string, router *Router, handlers []Handler, startupHan
 		posteriorHandlers: posteriorHandlers,
 		shutdownHandlers:  shutdownHandlers,
 	}
+	rg.lifecycle.Store(&lifecycle{startupHandlers, anteriorHandlers, posteriorHandlers, shutdownHandlers})
+	return rg
 }
 
+// GetStartupHandlers returns the startup handlers of the group, preceded by those of its parent groups.
 func (rg *RouteGroup) GetStartupHandlers() []Handler {
-	return rg.startupHandlers
+	return rg.getLifecycle().startup
 }
 
+// GetAnteriorHandlers returns the anterior handlers of the group, preceded by those of its parent groups.
 func (rg *RouteGroup) GetAnteriorHandlers() []Handler {
-	return rg.anteriorHandlers
+	return rg.getLifecycle().anterior
 }
 
+// GetPosteriorHandlers returns the posterior handlers of the group, preceded by those of its parent groups.
 func (rg *RouteGroup) GetPosteriorHandlers() []Handler {
-	return rg.posteriorHandlers
+	return rg.getLifecycle().posterior
 }
 
+// GetShutdownHandlers returns the shutdown handlers of the group, preceded by those of its parent groups.
 func (rg *RouteGroup) GetShutdownHandlers() []Handler {
-	return rg.shutdownHandlers
+	return rg.getLifecycle().shutdown
+}
+
+// getLifecycle returns the lifecycle handlers of the group combined with those of its parent groups.
+func (rg *RouteGroup) getLifecycle() *lifecycle {
+	return rg.lifecycle.Load().(*lifecycle)
+}
+
+// resolveLifecycle combines the lifecycle handlers of the group with those of its parent group,
+// and does the same for the groups created from it. It must be called with the router mutex held.
+func (rg *RouteGroup) resolveLifecycle() {
+	l := &lifecycle{rg.startupHandlers, rg.anteriorHandlers, rg.posteriorHandlers, rg.shutdownHandlers}
+	if rg.parent != nil {
+		p := rg.parent.getLifecycle()
+		l = &lifecycle{
+			startup:   combineHandlers(p.startup, l.startup),
+			anterior:  combineHandlers(p.anterior, l.anterior),
+			posterior: combineHandlers(p.posterior, l.posterior),
+			shutdown:  combineHandlers(p.shutdown, l.shutdown),
+		}
+	}
+	rg.lifecycle.Store(l)
+	for _, group := range rg.children {
+		group.resolveLifecycle()
+	}
+}
+
+// appendLifecycle appends the handlers to the given lifecycle handlers of the group, and resolves the lifecycle
+// handlers of the group, of the groups created from it and of the routes of the router again.
+func (rg *RouteGroup) appendLifecycle(target *[]Handler, handlers []Handler) {
+	r := rg.router
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	*target = combineHandlers(*target, handlers)
+	rg.resolveLifecycle()
+	t := r.pending
+	if t == nil {
+		t = r.table.Load().(*routeTable)
+	}
+	for _, route := range t.routes {
+		route.updateChain()
+	}
 }
 
 // Get adds a GET route to the router with the given route path and handlers.
@@ -108,41 +167,121 @@ func (rg *RouteGroup) To(methods, path string, handlers ...Handler) *Route {
 	}
 
 	r := rg.newRoute(methods, path)
-	for _, method := range mm {
-		r.routes = append(r.routes, rg.add(method, path, handlers))
-	}
+	rg.router.batch(func() {
+		for _, method := range mm {
+			r.routes = append(r.routes, rg.add(method, path, handlers))
+		}
+	})
 	return r
 }
 
+// Mount attaches the given router under the specified path prefix of the current route group.
+// Requests whose paths start with the prefix are dispatched to the sub-router with the prefix stripped,
+// after the handlers registered with the current group are executed. The sub-router keeps its own handlers,
+// not-found handlers and error handling. Its named routes can be resolved via Router.Route and Context.URL
+// of the parent router, with the prefix prepended to the generated URLs.
+// Parameters in the prefix are not passed to the sub-router.
+func (rg *RouteGroup) Mount(prefix string, sub *Router) {
+	prefix = strings.TrimRight(prefix, "/")
+	m := &mount{
+		template: buildURLTemplate(rg.hostPrefix() + rg.prefix + prefix),
+		router:   sub,
+	}
+	rg.router.batch(func() {
+		if prefix != "" {
+			rg.Any(prefix, func(c *Context) error {
+				return sub.serveMounted(c, "/")
+			})
+		}
+		rg.Any(prefix+"/*", func(c *Context) error {
+			// the wildcard parameter is always the last one
+			return sub.serveMounted(c, "/"+c.pvalues[len(c.pnames)-1])
+		})
+		rg.router.update(func(t *routeTable) {
+			t.mounts = append(t.mounts, m)
+		})
+	})
+}
+
 // Group creates a RouteGroup with the given route path prefix and handlers.
 // The new group will combine the existing path prefix with the new one.
 // If no handler is provided, the new group will inherit the handlers registered
 // with the current group.
+// The routes of the new group execute the startup, anterior, posterior and shutdown handlers of the current group,
+// including those appended after the new group is created, before the lifecycle handlers of the new group itself.
+// The lifecycle handlers appended to the new group do not affect the current group.
 func (rg *RouteGroup) Group(prefix string, handlers ...Handler) *RouteGroup {
 	if len(handlers) == 0 {
 		handlers = make([]Handler, len(rg.handlers))
 		copy(handlers, rg.handlers)
 	}
-	return newRouteGroup(rg.prefix+prefix, rg.router, handlers,
-		rg.startupHandlers, rg.anteriorHandlers, rg.posteriorHandlers, rg.shutdownHandlers)
+	group := newRouteGroup(rg.prefix+prefix, rg.router, handlers, nil, nil, nil, nil)
+	group.host = rg.host
+	group.parent = rg
+	rg.router.mu.Lock()
+	rg.children = append(rg.children, group)
+	group.resolveLifecycle()
+	rg.router.mu.Unlock()
+	return group
 }
 
-// Startup registers one or multiple handlers to the current route group.
-// These handlers will be shared by all routes belong to this group and its subgroups.
+// AppendStartupHandler registers handlers to be executed first when a route of the current group is matched.
+// The lifecycle handlers of a group apply to the routes of the group and of its subgroups,
+// including those added before the call.
 func (rg *RouteGroup) AppendStartupHandler(handlers ...Handler) {
-	rg.startupHandlers = append(rg.startupHandlers, handlers...)
+	rg.appendLifecycle(&rg.startupHandlers, handlers)
 }
 
+// AppendShutdownHandler registers handlers to be executed last when a route of the current group is matched,
+// even if the other handlers fail.
 func (rg *RouteGroup) AppendShutdownHandler(handlers ...Handler) {
-	rg.shutdownHandlers = append(rg.shutdownHandlers, handlers...)
+	rg.appendLifecycle(&rg.shutdownHandlers, handlers)
 }
 
+// AppendAnteriorHandler registers handlers to be executed after the startup handlers and before the route handlers
+// when a route of the current group is matched.
 func (rg *RouteGroup) AppendAnteriorHandler(handlers ...Handler) {
-	rg.anteriorHandlers = append(rg.anteriorHandlers, handlers...)
+	rg.appendLifecycle(&rg.anteriorHandlers, handlers)
 }
 
+// AppendPosteriorHandler registers handlers to be executed after the route handlers when a route of the current
+// group is matched, even if the route handlers fail.
 func (rg *RouteGroup) AppendPosteriorHandler(handlers ...Handler) {
-	rg.posteriorHandlers = append(rg.posteriorHandlers, handlers...)
+	rg.appendLifecycle(&rg.posteriorHandlers, handlers)
+}
+
+// NotFound specifies the handlers that should be invoked when no route matches a request whose path falls under
+// the path prefix of the group (and whose host matches the host pattern of the group, if any).
+// If multiple groups match the request, the one with the longest prefix is used. If no group matches,
+// the handlers set via Router.NotFound are used. Note that the handlers registered with the group
+// via Use before the call will be invoked first, followed by MethodNotAllowedHandler, so that a request
+// for a path having routes with other methods still receives a 405 response with the Allow header.
+// For example, the following code responds to unknown API paths with JSON errors while keeping
+// the default not-found handling for the other paths:
+//
+//	api := router.Group("/api")
+//	api.NotFound(func(c *routing.Context) error {
+//		c.Response().WriteHeader(http.StatusNotFound)
+//		return c.Write(map[string]string{"error": "not found"})
+//	})
+func (rg *RouteGroup) NotFound(handlers ...Handler) *RouteGroup {
+	rg.notFound = combineHandlers(rg.handlers, []Handler{MethodNotAllowedHandler}, handlers)
+	rg.router.update(func(t *routeTable) {
+		t.addScope(rg)
+	})
+	return rg
+}
+
+// OnError specifies the function that handles the error returned by the handlers of a request whose path falls under
+// the path prefix of the group (and whose host matches the host pattern of the group, if any).
+// The group is determined by the request path the same way as in NotFound, and the error handler
+// of the router is used if no group matches. The function is not called if the response has already been written.
+func (rg *RouteGroup) OnError(fn func(*Context, error)) *RouteGroup {
+	rg.errorHandler = fn
+	rg.router.update(func(t *routeTable) {
+		t.addScope(rg)
+	})
+	return rg
 }
 
 // Use registers one or multiple handlers to the current route group.
@@ -163,8 +302,16 @@ func (rg *RouteGroup) newRoute(method, path string) *Route {
 		group:    rg,
 		method:   method,
 		path:     path,
-		template: buildURLTemplate(rg.prefix + path),
+		template: buildURLTemplate(rg.hostPrefix() + rg.prefix + path),
+	}
+}
+
+// hostPrefix returns the scheme-relative URL prefix for the host pattern of the group, if any.
+func (rg *RouteGroup) hostPrefix() string {
+	if rg.host == "" {
+		return ""
 	}
+	return "//" + rg.host
 }
 
 // combineHandlers merges multiple lists of handlers into a new list.
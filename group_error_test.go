@@ -0,0 +1,112 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteGroupNotFound(t *testing.T) {
+	r := New()
+	r.Get("/home", func(c *Context) error { return c.Write("home") })
+	api := r.Group("/api")
+	api.Use(func(c *Context) error {
+		c.Response().Header().Set("X-API", "yes")
+		return nil
+	})
+	api.NotFound(func(c *Context) error {
+		c.Response().WriteHeader(http.StatusNotFound)
+		return c.Write(`{"error":"not found"}`)
+	})
+	api.Get("/users", func(c *Context) error { return c.Write("users") })
+	v2 := api.Group("/v2/<version:\\d+>")
+	v2.NotFound(func(c *Context) error { return c.Write("v2 not found") })
+	tenant := r.Host("<tenant>.example.com")
+	tenant.Group("/api").NotFound(func(c *Context) error { return c.Write("tenant not found") })
+
+	tests := []struct {
+		host, path string
+		status     int
+		body       string
+	}{
+		{"", "/api/users", http.StatusOK, "users"},
+		{"", "/api/missing", http.StatusNotFound, `{"error":"not found"}`},
+		{"", "/api", http.StatusNotFound, `{"error":"not found"}`},
+		{"", "/apis", http.StatusNotFound, "Not Found\n"},
+		{"", "/missing", http.StatusNotFound, "Not Found\n"},
+		{"", "/api/v2/1/missing", http.StatusOK, "v2 not found"},
+		{"", "/api/v2/a/missing", http.StatusNotFound, `{"error":"not found"}`},
+		{"acme.example.com", "/api/missing", http.StatusOK, "tenant not found"},
+		{"example.com", "/api/missing", http.StatusNotFound, `{"error":"not found"}`},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.path, nil)
+		req.Host = test.host
+		r.ServeHTTP(res, req)
+		assert.Equal(t, test.status, res.Code, test.host+test.path)
+		assert.Equal(t, test.body, res.Body.String(), test.host+test.path)
+	}
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/missing", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, "yes", res.Header().Get("X-API"))
+
+	// the requests with unsupported methods are answered before the not-found handlers of the group
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/users", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+	assert.Equal(t, "GET, OPTIONS", res.Header().Get("Allow"))
+	assert.Equal(t, "yes", res.Header().Get("X-API"))
+}
+
+func TestRouteGroupOnError(t *testing.T) {
+	r := New()
+	fail := func(c *Context) error { return NewHTTPError(http.StatusBadRequest, "bad input") }
+	r.Get("/page", fail)
+	api := r.Group("/api")
+	api.OnError(func(c *Context, err error) {
+		c.Response().WriteHeader(err.(HTTPError).StatusCode())
+		c.Write(`{"error":"` + err.Error() + `","id":"` + c.Param("id") + `"}`)
+	})
+	api.Get("/users/<id>", fail)
+	api.Get("/partial", func(c *Context) error {
+		c.Write("partial")
+		return fail(c)
+	})
+	api.NotFound(func(c *Context) error { return NewHTTPError(http.StatusNotFound) })
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/page", http.StatusBadRequest, "bad input\n"},
+		{"/api/users/1", http.StatusBadRequest, `{"error":"bad input","id":"1"}`},
+		{"/api/missing", http.StatusNotFound, `{"error":"Not Found","id":""}`},
+		{"/api/partial", http.StatusOK, "partial"},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.path, nil)
+		r.ServeHTTP(res, req)
+		assert.Equal(t, test.status, res.Code, test.path)
+		assert.Equal(t, test.body, res.Body.String(), test.path)
+	}
+
+	// the error handler of the router itself applies to every path
+	r.OnError(func(c *Context, err error) {
+		c.Response().WriteHeader(http.StatusTeapot)
+	})
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/page", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusTeapot, res.Code)
+}
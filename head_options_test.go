@@ -0,0 +1,116 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterAutoHeadOptions(t *testing.T) {
+	r := New()
+	h := func(c *Context) error {
+		c.ResponseWriter.Header().Set("X-Handler", c.Request.Method)
+		fmt.Fprint(c.ResponseWriter, "ok")
+		return nil
+	}
+	r.Get("/users", h)
+	r.Post("/users", h)
+	r.Get("/items", h)
+	r.Head("/items", func(c *Context) error {
+		c.ResponseWriter.Header().Set("X-Handler", "explicit")
+		return nil
+	})
+	r.Options("/items", func(c *Context) error {
+		c.ResponseWriter.Header().Set("Allow", "GET")
+		return nil
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("HEAD", "/users", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+	assert.Equal(t, "GET, OPTIONS, POST", res.Header().Get("Allow"))
+
+	r.AutoHead = true
+	r.AutoOptions = true
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("HEAD", "/users", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "HEAD", res.Header().Get("X-Handler"))
+	assert.Equal(t, "", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("HEAD", "/items", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, "explicit", res.Header().Get("X-Handler"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("OPTIONS", "/users", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS, POST", res.Header().Get("Allow"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("OPTIONS", "/items", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, "GET", res.Header().Get("Allow"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/users", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS, POST", res.Header().Get("Allow"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("OPTIONS", "/unknown", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestRouterAutoHeadDiscardsBody(t *testing.T) {
+	r := New()
+	r.AutoHead = true
+	r.AppendAnteriorHandler(func(c *Context) error {
+		c.Response().Header().Set("X-Anterior", "yes")
+		fmt.Fprint(c.Response(), "anterior")
+		return nil
+	})
+	r.Get("/users", func(c *Context) error {
+		fmt.Fprint(c.ResponseWriter, "writer")
+		_, err := c.Response().ReadFrom(strings.NewReader("response"))
+		return err
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("HEAD", "/users", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "yes", res.Header().Get("X-Anterior"))
+	assert.Equal(t, "", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, "anteriorwriterresponse", res.Body.String())
+
+	var size int64
+	r.AppendPosteriorHandler(func(c *Context) error {
+		size = c.Response().Size()
+		return nil
+	})
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("HEAD", "/users", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, "", res.Body.String())
+	assert.Equal(t, int64(len("anteriorwriterresponse")), size)
+}
@@ -0,0 +1,77 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterHost(t *testing.T) {
+	r := New()
+	h := func(c *Context) error {
+		fmt.Fprintf(c.ResponseWriter, "%v:%v:%v", c.Param("tenant"), c.Param("id"), len(c.pnames))
+		return nil
+	}
+	r.Get("/users/<id>", func(c *Context) error {
+		fmt.Fprint(c.ResponseWriter, "any:"+c.Param("id"))
+		return nil
+	})
+	r.Host("<tenant>.example.com").Get("/users/<id>", h).Name("tenant-user")
+	r.Host("admin.example.com").Group("/admin").Get("/users/<id>", h).Name("admin-user")
+
+	tests := []struct {
+		host, path, body string
+		status           int
+	}{
+		{"acme.example.com", "/users/1", "acme:1:2", http.StatusOK},
+		{"ACME.example.com:8080", "/users/2", "acme:2:2", http.StatusOK},
+		{"admin.example.com", "/admin/users/3", ":3:1", http.StatusOK},
+		{"admin.example.com", "/users/4", "admin:4:2", http.StatusOK},
+		{"a.b.example.com", "/users/5", "any:5", http.StatusOK},
+		{"example.org", "/users/6", "any:6", http.StatusOK},
+		{"example.org", "/admin/users/7", "Not Found\n", http.StatusNotFound},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.path, nil)
+		req.Host = test.host
+		r.ServeHTTP(res, req)
+		assert.Equal(t, test.status, res.Code, test.host+test.path)
+		assert.Equal(t, test.body, res.Body.String(), test.host+test.path)
+	}
+
+	handlers, params := r.Find("GET", "/users/8", "beta.example.com")
+	assert.Equal(t, 1, len(handlers))
+	assert.Equal(t, map[string]string{"tenant": "beta", "id": "8"}, params)
+
+	assert.Equal(t, "<tenant>.example.com", r.Route("tenant-user").Host())
+	assert.Equal(t, "//acme.example.com/users/9", r.Route("tenant-user").URL("tenant", "acme", "id", 9))
+	assert.Equal(t, "//admin.example.com/admin/users/9", r.Route("admin-user").URL("id", 9))
+	assert.Equal(t, "GET //admin.example.com/admin/users/<id>", r.Route("admin-user").String())
+}
+
+func TestNormalizeRequestHost(t *testing.T) {
+	assert.Equal(t, "example.com", normalizeRequestHost("Example.COM:80"))
+	assert.Equal(t, "example.com", normalizeRequestHost("example.com"))
+	assert.Equal(t, "[::1]", normalizeRequestHost("[::1]:80"))
+	assert.Equal(t, "[::1]", normalizeRequestHost("[::1]"))
+	assert.Equal(t, "<tenant:[^.]+>.example.com", buildHostPattern("<tenant>.example.com"))
+	assert.Equal(t, "<id:\\d+>.example.com", buildHostPattern("<id:\\d+>.example.com"))
+	assert.Equal(t, "<Tenant:[^.]+>.api.example.com", buildHostPattern("<Tenant>.API.Example.com"))
+
+	r := New()
+	r.Host("API.example.com").Get("/users", func(c *Context) error {
+		return c.Write("api")
+	})
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "http://api.example.com/users", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, "api", res.Body.String())
+}
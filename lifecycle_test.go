@@ -0,0 +1,224 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterLifecycle(t *testing.T) {
+	var buf bytes.Buffer
+	write := func(s string) Handler {
+		return func(c *Context) error {
+			buf.WriteString(s)
+			return nil
+		}
+	}
+	r := New()
+	r.AppendStartupHandler(write("startup,"))
+	r.AppendAnteriorHandler(write("anterior,"))
+	r.AppendPosteriorHandler(func(c *Context) error {
+		fmt.Fprintf(&buf, "posterior(%v),", c.HandlerError())
+		return nil
+	})
+	r.AppendShutdownHandler(func(c *Context) error {
+		fmt.Fprintf(&buf, "shutdown(%v)", c.HandlerError())
+		return nil
+	})
+	r.Get("/ok", write("ok,"))
+	r.Get("/error", func(c *Context) error {
+		return NewHTTPError(http.StatusConflict)
+	}, write("skipped,"))
+	r.Get("/abort", func(c *Context) error {
+		c.Abort()
+		return nil
+	}, write("skipped,"))
+	r.Get("/panic", func(c *Context) error {
+		panic("boom")
+	}).AppendShutdownHandler(write("route-shutdown,"))
+
+	tests := []struct {
+		path, log string
+		status    int
+	}{
+		{"/ok", "startup,anterior,ok,posterior(<nil>),shutdown(<nil>)", http.StatusOK},
+		{"/error", "startup,anterior,posterior(Conflict),shutdown(Conflict)", http.StatusConflict},
+		{"/abort", "startup,anterior,posterior(<nil>),shutdown(<nil>)", http.StatusOK},
+		{"/missing", "startup,anterior,posterior(Not Found),shutdown(Not Found)", http.StatusNotFound},
+	}
+	for _, test := range tests {
+		buf.Reset()
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.path, nil)
+		r.ServeHTTP(res, req)
+		assert.Equal(t, test.log, buf.String(), test.path)
+		assert.Equal(t, test.status, res.Code, test.path)
+	}
+
+	// the shutdown handlers are executed even if a handler panics
+	buf.Reset()
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	assert.Panics(t, func() {
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	})
+	assert.Equal(t, "startup,anterior,route-shutdown,shutdown(<nil>)", buf.String())
+
+	// the errors of all phases are combined
+	r = New()
+	r.AppendPosteriorHandler(func(c *Context) error {
+		return errors.New("posterior failed")
+	})
+	r.AppendShutdownHandler(func(c *Context) error {
+		return errors.New("shutdown failed")
+	}, write("shutdown,"))
+	r.Get("/error", func(c *Context) error {
+		return NewHTTPError(http.StatusConflict)
+	})
+	buf.Reset()
+	res := httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/error", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, "shutdown,", buf.String())
+	assert.Equal(t, http.StatusConflict, res.Code)
+	assert.Equal(t, "Conflict; posterior failed; shutdown failed\n", res.Body.String())
+}
+
+func TestRouteGroupLifecycle(t *testing.T) {
+	var buf bytes.Buffer
+	write := func(s string) Handler {
+		return func(c *Context) error {
+			buf.WriteString(s)
+			return nil
+		}
+	}
+	r := New()
+	r.AppendStartupHandler(write("root-startup,"))
+	r.AppendShutdownHandler(write("root-shutdown"))
+	api := r.Group("/api")
+	admin := r.Group("/admin")
+	// the backing arrays of the parent must not be shared with the subgroups
+	api.AppendStartupHandler(write("api-startup,"))
+	admin.AppendStartupHandler(write("admin-startup,"))
+	api.AppendPosteriorHandler(write("api-posterior,"))
+
+	r.Get("/home", write("home,"))
+	users := api.Get("/users", write("users,"))
+	admin.Get("/users", write("admin-users,"))
+	// lifecycle handlers appended after routes are added apply at match time
+	admin.AppendAnteriorHandler(write("admin-anterior,"))
+
+	tests := []struct {
+		path, log string
+	}{
+		{"/home", "root-startup,home,root-shutdown"},
+		{"/api/users", "root-startup,api-startup,users,api-posterior,root-shutdown"},
+		{"/admin/users", "root-startup,admin-startup,admin-anterior,admin-users,root-shutdown"},
+		{"/missing", "root-startup,root-shutdown"},
+	}
+	for _, test := range tests {
+		buf.Reset()
+		req, _ := http.NewRequest("GET", test.path, nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t, test.log, buf.String(), test.path)
+	}
+	assert.Equal(t, 1, len(r.startupHandlers))
+	assert.Equal(t, 0, len(r.posteriorHandlers))
+
+	users.AppendShutdownHandler(write("users-shutdown,"))
+	buf.Reset()
+	c := NewContext(nil, nil)
+	for _, h := range users.HandlerChain() {
+		h(c)
+	}
+	assert.Equal(t, "root-startup,api-startup,users,api-posterior,users-shutdown,root-shutdown", buf.String())
+}
+
+func TestRouteGroupLifecycleInheritance(t *testing.T) {
+	var buf bytes.Buffer
+	write := func(s string) Handler {
+		return func(c *Context) error {
+			buf.WriteString(s)
+			return nil
+		}
+	}
+	r := New()
+	api := r.Group("/api")
+	v1 := api.Group("/v1")
+	tenant := r.Host("<tenant>.example.com")
+	api.Get("/x", write("x,"))
+	v1.Get("/x", write("v1-x,"))
+	tenant.Get("/x", write("tenant-x,"))
+	r.Get("/y", write("y,"))
+	// the handlers appended to the parent groups after the subgroups are created still apply to them
+	r.AppendStartupHandler(write("start,"))
+	r.AppendAnteriorHandler(write("anterior,"))
+	r.AppendPosteriorHandler(write("posterior,"))
+	r.AppendShutdownHandler(write("shutdown"))
+	api.AppendStartupHandler(write("api-start,"))
+	v1.AppendPosteriorHandler(write("v1-posterior,"))
+
+	tests := []struct {
+		host, path, log string
+	}{
+		{"", "/y", "start,anterior,y,posterior,shutdown"},
+		{"", "/api/x", "start,api-start,anterior,x,posterior,shutdown"},
+		{"", "/api/v1/x", "start,api-start,anterior,v1-x,posterior,v1-posterior,shutdown"},
+		{"acme.example.com", "/x", "start,anterior,tenant-x,posterior,shutdown"},
+	}
+	for _, test := range tests {
+		buf.Reset()
+		req, _ := http.NewRequest("GET", test.path, nil)
+		req.Host = test.host
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t, test.log, buf.String(), test.host+test.path)
+	}
+	assert.Len(t, r.GetStartupHandlers(), 1)
+	assert.Len(t, api.GetStartupHandlers(), 2)
+	assert.Len(t, v1.GetStartupHandlers(), 2)
+}
+
+func TestRouteGroupLifecycleConcurrentAppend(t *testing.T) {
+	var count int32
+	count1 := func(c *Context) error {
+		atomic.AddInt32(&count, 1)
+		return nil
+	}
+	r := New()
+	v1 := r.Group("/api").Group("/v1")
+	route := v1.Get("/users", count1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				req, _ := http.NewRequest("GET", "/api/v1/users", nil)
+				r.ServeHTTP(httptest.NewRecorder(), req)
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		r.AppendAnteriorHandler(count1)
+		v1.AppendPosteriorHandler(count1)
+	}
+	wg.Wait()
+
+	// the lifecycle handlers are resolved in the handler chain of the route
+	assert.Len(t, route.HandlerChain(), 21)
+	atomic.StoreInt32(&count, 0)
+	req, _ := http.NewRequest("GET", "/api/v1/users", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, int32(21), atomic.LoadInt32(&count))
+}
@@ -0,0 +1,65 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteGroupMount(t *testing.T) {
+	sub := New()
+	sub.Use(func(c *Context) error {
+		fmt.Fprint(c.ResponseWriter, "sub,")
+		return nil
+	})
+	sub.NotFound(func(c *Context) error {
+		fmt.Fprint(c.ResponseWriter, "missing:"+c.Request.URL.Path)
+		return nil
+	})
+	sub.Get("/", func(c *Context) error {
+		fmt.Fprint(c.ResponseWriter, "index")
+		return nil
+	})
+	sub.Get("/invoices/<id>", func(c *Context) error {
+		fmt.Fprint(c.ResponseWriter, "invoice:"+c.Param("id"))
+		return nil
+	}).Name("invoice")
+
+	r := New()
+	api := r.Group("/api", func(c *Context) error {
+		fmt.Fprint(c.ResponseWriter, "api,")
+		return nil
+	})
+	api.Mount("/billing/", sub)
+
+	tests := []struct {
+		path, body string
+	}{
+		{"/api/billing", "api,sub,index"},
+		{"/api/billing/", "api,sub,index"},
+		{"/api/billing/invoices/12", "api,sub,invoice:12"},
+		{"/api/billing/unknown", "api,sub,missing:/unknown"},
+		{"/api/billingx", "Not Found\n"},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.path, nil)
+		r.ServeHTTP(res, req)
+		assert.Equal(t, test.body, res.Body.String(), test.path)
+	}
+
+	if assert.NotNil(t, r.Route("invoice")) {
+		assert.Equal(t, "/api/billing/invoices/12", r.Route("invoice").URL("id", 12))
+	}
+	assert.Equal(t, "/invoices/12", sub.Route("invoice").URL("id", 12))
+	c := &Context{router: r}
+	assert.Equal(t, "/api/billing/invoices/12", c.URL("invoice", "id", 12))
+	assert.Nil(t, r.Route("unknown"))
+}
@@ -0,0 +1,111 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"mime/multipart"
+	"net/http"
+)
+
+// DefaultMaxMemory is the maximum number of bytes of a multipart form that are kept in memory while parsing the form.
+// The rest of the uploaded files is stored in temporary files. It can be changed for individual routes via UploadLimit.
+var DefaultMaxMemory int64 = 32 << 20
+
+// UploadLimit returns a handler that sets the limits used by the rest of the route handlers when parsing
+// a multipart form. maxMemory is the maximum number of bytes kept in memory, and zero means DefaultMaxMemory.
+// maxSize is the maximum size of the request body, and zero means no limit. A larger body results in a 413 HTTP error.
+// For example,
+//
+//	router.Post("/avatars", routing.UploadLimit(1<<20, 10<<20), uploadAvatar)
+func UploadLimit(maxMemory, maxSize int64) Handler {
+	return func(c *Context) error {
+		c.SetUploadLimit(maxMemory, maxSize)
+		return nil
+	}
+}
+
+// SetUploadLimit sets the limits used when parsing a multipart form of the current request.
+// It must be called before the form is parsed. Please refer to UploadLimit for the meaning of the parameters.
+func (c *Context) SetUploadLimit(maxMemory, maxSize int64) {
+	c.maxMemory, c.maxUploadSize = maxMemory, maxSize
+}
+
+// FormFile returns the first file uploaded under the given name in the multipart form.
+// It returns http.ErrMissingFile if there is no such file. The returned file should be closed after use.
+func (c *Context) FormFile(name string) (multipart.File, *multipart.FileHeader, error) {
+	files, err := c.MultipartFiles(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := files[0].Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, files[0], nil
+}
+
+// MultipartFiles returns all files uploaded under the given name in the multipart form.
+// It returns http.ErrMissingFile if there is no such file.
+func (c *Context) MultipartFiles(name string) ([]*multipart.FileHeader, error) {
+	if err := c.parseMultipartForm(); err != nil {
+		return nil, err
+	}
+	if form := c.Request.MultipartForm; form != nil {
+		if files := form.File[name]; len(files) > 0 {
+			return files, nil
+		}
+	}
+	return nil, http.ErrMissingFile
+}
+
+// MultipartReader returns a reader that streams the parts of a multipart request body one by one,
+// without keeping the uploaded files in memory or in temporary files. The size limit set via UploadLimit applies,
+// and reading beyond it results in a 413 HTTP error. The reader cannot be used together with the methods that
+// parse the whole form, such as FormFile, Form and Read.
+func (c *Context) MultipartReader() (*multipart.Reader, error) {
+	if err := c.limitBody(); err != nil {
+		return nil, err
+	}
+	return c.Request.MultipartReader()
+}
+
+// parseMultipartForm parses the request body as a multipart form according to the upload limits.
+// A body that is not a multipart form is parsed as a URL-encoded form.
+func (c *Context) parseMultipartForm() error {
+	if c.Request.MultipartForm != nil {
+		return nil
+	}
+	if err := c.limitBody(); err != nil {
+		return err
+	}
+	maxMemory := c.maxMemory
+	if maxMemory <= 0 {
+		maxMemory = DefaultMaxMemory
+	}
+	err := c.Request.ParseMultipartForm(maxMemory)
+	if body, ok := c.Request.Body.(*limitedReader); ok && body.n < 0 {
+		return errBodyTooLarge
+	}
+	if err == http.ErrNotMultipart {
+		return nil
+	}
+	return err
+}
+
+// limitBody limits the size of the request body according to the upload limit.
+func (c *Context) limitBody() error {
+	body := c.Request.Body
+	if c.maxUploadSize <= 0 || body == nil || body == http.NoBody {
+		return nil
+	}
+	if _, ok := body.(*limitedReader); ok {
+		return nil
+	}
+	if c.Request.ContentLength > c.maxUploadSize {
+		return errBodyTooLarge
+	}
+	c.Request.Body = &limitedReader{body, c.maxUploadSize}
+	return nil
+}
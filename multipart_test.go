@@ -0,0 +1,152 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newMultipartRequest creates a request with a multipart body containing the given form values and files.
+// The files are given as pairs of field names and file contents.
+func newMultipartRequest(values map[string]string, files ...string) *http.Request {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, value := range values {
+		w.WriteField(name, value)
+	}
+	for i := 0; i+1 < len(files); i += 2 {
+		fw, _ := w.CreateFormFile(files[i], files[i]+".txt")
+		io.WriteString(fw, files[i+1])
+	}
+	w.Close()
+	req, _ := http.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func TestContextFormFile(t *testing.T) {
+	c := NewContext(nil, newMultipartRequest(map[string]string{"name": "john"}, "avatar", "abc", "docs", "d1", "docs", "d2"))
+
+	f, fh, err := c.FormFile("avatar")
+	if assert.Nil(t, err) {
+		data, _ := ioutil.ReadAll(f)
+		f.Close()
+		assert.Equal(t, "abc", string(data))
+		assert.Equal(t, "avatar.txt", fh.Filename)
+	}
+	files, err := c.MultipartFiles("docs")
+	if assert.Nil(t, err) {
+		assert.Len(t, files, 2)
+	}
+	assert.Equal(t, "john", c.Form("name"))
+
+	_, _, err = c.FormFile("missing")
+	assert.Equal(t, http.ErrMissingFile, err)
+	_, err = NewContext(nil, httptest.NewRequest("GET", "/", nil)).MultipartFiles("avatar")
+	assert.Equal(t, http.ErrMissingFile, err)
+}
+
+func TestUploadLimit(t *testing.T) {
+	req := newMultipartRequest(nil, "avatar", strings.Repeat("a", 1000))
+	c := NewContext(nil, req, UploadLimit(0, 100))
+	c.Next()
+	_, _, err := c.FormFile("avatar")
+	assert.Equal(t, errBodyTooLarge, err)
+
+	// the size is checked while reading the body if the content length is unknown
+	req = newMultipartRequest(nil, "avatar", strings.Repeat("a", 1000))
+	req.ContentLength = -1
+	c = NewContext(nil, req)
+	c.SetUploadLimit(10, 100)
+	_, err = c.MultipartFiles("avatar")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, err.(HTTPError).StatusCode())
+
+	req = newMultipartRequest(nil, "avatar", strings.Repeat("a", 1000))
+	c = NewContext(nil, req)
+	c.SetUploadLimit(10, 2000)
+	_, fh, err := c.FormFile("avatar")
+	if assert.Nil(t, err) {
+		assert.Equal(t, int64(1000), fh.Size)
+	}
+}
+
+func TestContextMultipartReader(t *testing.T) {
+	c := NewContext(nil, newMultipartRequest(nil, "a", "123", "b", "456"))
+	mr, err := c.MultipartReader()
+	if assert.Nil(t, err) {
+		var names []string
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if !assert.Nil(t, err) {
+				break
+			}
+			data, _ := ioutil.ReadAll(part)
+			names = append(names, part.FormName()+"="+string(data))
+		}
+		assert.Equal(t, []string{"a=123", "b=456"}, names)
+	}
+
+	req := newMultipartRequest(nil, "a", strings.Repeat("a", 1000))
+	req.ContentLength = -1
+	c = NewContext(nil, req)
+	c.SetUploadLimit(0, 100)
+	mr, err = c.MultipartReader()
+	if assert.Nil(t, err) {
+		// the error may occur when reading the part header or the part content due to buffering
+		part, err := mr.NextPart()
+		if err == nil {
+			_, err = ioutil.ReadAll(part)
+		}
+		assert.Equal(t, errBodyTooLarge, err)
+	}
+
+	_, err = NewContext(nil, httptest.NewRequest("POST", "/", strings.NewReader("a=b"))).MultipartReader()
+	assert.NotNil(t, err)
+}
+
+func TestReadFormFiles(t *testing.T) {
+	type upload struct {
+		Name    string                  `form:"name"`
+		Avatar  *multipart.FileHeader   `form:"avatar"`
+		Docs    []*multipart.FileHeader `form:"docs"`
+		Profile struct {
+			Photo *multipart.FileHeader `form:"photo"`
+		} `form:"profile"`
+	}
+
+	var data upload
+	c := NewContext(nil, newMultipartRequest(map[string]string{"name": "john"}, "avatar", "abc", "docs", "d1", "docs", "d2", "profile.photo", "p"))
+	if assert.Nil(t, c.Read(&data)) {
+		assert.Equal(t, "john", data.Name)
+		if assert.NotNil(t, data.Avatar) {
+			assert.Equal(t, "avatar.txt", data.Avatar.Filename)
+		}
+		assert.Len(t, data.Docs, 2)
+		assert.NotNil(t, data.Profile.Photo)
+	}
+
+	data = upload{}
+	files := map[string][]*multipart.FileHeader{"avatar": {{Filename: "a.png"}}}
+	assert.Nil(t, ReadFormData(map[string][]string{"name": {"john"}}, &data, files))
+	assert.Equal(t, "a.png", data.Avatar.Filename)
+	assert.Nil(t, data.Docs)
+
+	req := newMultipartRequest(nil, "avatar", strings.Repeat("a", 1000))
+	c = NewContext(nil, req, UploadLimit(0, 100))
+	c.Next()
+	assert.Equal(t, errBodyTooLarge, c.Read(&data))
+}
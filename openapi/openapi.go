@@ -0,0 +1,387 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package openapi generates OpenAPI 3 documents from the routes registered with a router.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ltick/tick-routing"
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the version of the OpenAPI specification that generated documents conform to.
+const Version = "3.0.3"
+
+type (
+	// Document represents an OpenAPI document.
+	Document struct {
+		OpenAPI    string              `json:"openapi" yaml:"openapi"`
+		Info       Info                `json:"info" yaml:"info"`
+		Servers    []Server            `json:"servers,omitempty" yaml:"servers,omitempty"`
+		Paths      map[string]PathItem `json:"paths" yaml:"paths"`
+		Components *Components         `json:"components,omitempty" yaml:"components,omitempty"`
+	}
+
+	// Info provides the metadata about the API.
+	Info struct {
+		Title       string `json:"title" yaml:"title"`
+		Description string `json:"description,omitempty" yaml:"description,omitempty"`
+		Version     string `json:"version" yaml:"version"`
+	}
+
+	// Server represents a server hosting the API.
+	Server struct {
+		URL         string `json:"url" yaml:"url"`
+		Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	}
+
+	// PathItem describes the operations available on a path, indexed by lower case HTTP methods.
+	PathItem map[string]*Operation
+
+	// Operation describes an API operation on a path.
+	Operation struct {
+		OperationID string               `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+		Summary     string               `json:"summary,omitempty" yaml:"summary,omitempty"`
+		Description string               `json:"description,omitempty" yaml:"description,omitempty"`
+		Tags        []string             `json:"tags,omitempty" yaml:"tags,omitempty"`
+		Parameters  []*Parameter         `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+		RequestBody *RequestBody         `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+		Responses   map[string]*Response `json:"responses" yaml:"responses"`
+		Deprecated  bool                 `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	}
+
+	// Parameter describes a path or query parameter of an operation.
+	Parameter struct {
+		Name        string  `json:"name" yaml:"name"`
+		In          string  `json:"in" yaml:"in"`
+		Description string  `json:"description,omitempty" yaml:"description,omitempty"`
+		Required    bool    `json:"required,omitempty" yaml:"required,omitempty"`
+		Schema      *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+	}
+
+	// RequestBody describes the request body of an operation.
+	RequestBody struct {
+		Description string                `json:"description,omitempty" yaml:"description,omitempty"`
+		Required    bool                  `json:"required,omitempty" yaml:"required,omitempty"`
+		Content     map[string]*MediaType `json:"content" yaml:"content"`
+	}
+
+	// Response describes a response of an operation.
+	Response struct {
+		Description string                `json:"description" yaml:"description"`
+		Content     map[string]*MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+	}
+
+	// MediaType describes the content of a request or response body in a media type.
+	MediaType struct {
+		Schema *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+	}
+
+	// Components holds the reusable schemas referenced in the document.
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	}
+
+	// Meta describes the operation of a route. It should be attached to the route via Route.Tag, for example,
+	//
+	//     router.Post("/users", createUser).Tag(openapi.Meta{
+	//         Summary:   "Create a user",
+	//         Request:   User{},
+	//         Responses: map[int]interface{}{201: User{}},
+	//     })
+	Meta struct {
+		OperationID string
+		Summary     string
+		Description string
+		Tags        []string
+		Deprecated  bool
+		// a value of the struct type whose fields describe the query parameters. Fields are named after their "form" tags.
+		Query interface{}
+		// a value of the type of the JSON request body, nil if the operation has no request body
+		Request interface{}
+		// values of the types of the JSON response bodies, indexed by HTTP status codes. Use nil for responses without a body.
+		Responses map[int]interface{}
+	}
+)
+
+// ParamTypes lists the schemas of the typed parameter tokens that can be used in route patterns, such as "<id:int>".
+// If a custom type is registered via routing.RegisterParamType, its schema should be added here as well.
+// Otherwise the type name will be treated as a regular expression.
+var ParamTypes = map[string]*Schema{
+	"int":  {Type: "integer", Format: "int64"},
+	"uint": {Type: "integer", Minimum: float(0)},
+	"uuid": {Type: "string", Format: "uuid"},
+	"slug": {Type: "string", Pattern: "^[a-z0-9]+(-[a-z0-9]+)*$"},
+	"date": {Type: "string", Format: "date"},
+}
+
+// JSONType is the media type of request and response bodies described in generated documents.
+const JSONType = "application/json"
+
+// Generate creates an OpenAPI document describing the routes of the router.
+//
+// Route patterns are converted into OpenAPI paths, and their parameter tokens into path parameters.
+// Typed tokens, such as "<id:int>", are described using the schemas in ParamTypes, and tokens with regular
+// expressions, such as "<id:\d+>", are described as strings with the expression as the pattern.
+// The operation of a route is described using the Meta tagged to the route, if any.
+//
+// Routes with wildcard patterns, such as "/files/*", and routes using the CONNECT method cannot be described
+// by OpenAPI and are skipped. Hosts are ignored, so routes registered via Router.Host are described
+// as if they matched any host. If multiple routes have the same method and path, the first one is used.
+func Generate(router *routing.Router, info Info) *Document {
+	doc := &Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Paths:      make(map[string]PathItem),
+		Components: &Components{Schemas: make(map[string]*Schema)},
+	}
+	b := newSchemaBuilder(doc.Components.Schemas)
+	for _, route := range router.Routes() {
+		method := strings.ToLower(route.Method())
+		if method == "connect" {
+			continue
+		}
+		path, params, ok := convertPath(route.Path())
+		if !ok {
+			continue
+		}
+		item := doc.Paths[path]
+		if item == nil {
+			item = make(PathItem)
+			doc.Paths[path] = item
+		}
+		if item[method] == nil {
+			item[method] = newOperation(b, params, findMeta(route))
+		}
+	}
+	if len(doc.Components.Schemas) == 0 {
+		doc.Components = nil
+	}
+	return doc
+}
+
+// Handler returns a handler that serves the OpenAPI document of the router.
+// The document is generated for every request so that it reflects the routes currently registered.
+// It is served in YAML if the request path ends with ".yaml" or ".yml", and in JSON otherwise.
+// For example,
+//
+//	h := openapi.Handler(router, openapi.Info{Title: "API", Version: "1.0"})
+//	router.Get("/openapi.json", h)
+//	router.Get("/openapi.yaml", h)
+func Handler(router *routing.Router, info Info) routing.Handler {
+	return func(c *routing.Context) error {
+		doc := Generate(router, info)
+		var (
+			data []byte
+			err  error
+		)
+		if path := c.Request.URL.Path; strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml") {
+			c.ResponseWriter.Header().Set("Content-Type", "application/yaml")
+			data, err = yaml.Marshal(doc)
+		} else {
+			c.ResponseWriter.Header().Set("Content-Type", JSONType)
+			data, err = json.MarshalIndent(doc, "", "  ")
+		}
+		if err != nil {
+			return err
+		}
+		_, err = c.ResponseWriter.Write(data)
+		return err
+	}
+}
+
+// Load reads the OpenAPI document from the given JSON or YAML file.
+// Only the parts of the document described by the types in this package are loaded. For example,
+// path-level parameters and schema compositions such as "allOf" are ignored.
+func Load(file string) (*Document, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	doc := &Document{}
+	// JSON is a subset of YAML, so both formats can be decoded by the YAML decoder
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("failed to load OpenAPI document %v: %v", file, err)
+	}
+	return doc, nil
+}
+
+// operationMethods lists the keys of a path item that describe operations.
+var operationMethods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true,
+	"options": true, "head": true, "patch": true, "trace": true,
+}
+
+// UnmarshalJSON decodes the operations of a path item, ignoring the other fields.
+func (p *PathItem) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*p = make(PathItem)
+	for key, value := range fields {
+		if operationMethods[key] {
+			op := &Operation{}
+			if err := json.Unmarshal(value, op); err != nil {
+				return err
+			}
+			(*p)[key] = op
+		}
+	}
+	return nil
+}
+
+// UnmarshalYAML decodes the operations of a path item, ignoring the other fields.
+func (p *PathItem) UnmarshalYAML(value *yaml.Node) error {
+	var fields map[string]yaml.Node
+	if err := value.Decode(&fields); err != nil {
+		return err
+	}
+	*p = make(PathItem)
+	for key, node := range fields {
+		if operationMethods[key] {
+			op := &Operation{}
+			if err := node.Decode(op); err != nil {
+				return err
+			}
+			(*p)[key] = op
+		}
+	}
+	return nil
+}
+
+// findMeta returns the Meta tagged to the route, or nil if there is none.
+func findMeta(route *routing.Route) *Meta {
+	for _, tag := range route.Tags() {
+		switch meta := tag.(type) {
+		case Meta:
+			return &meta
+		case *Meta:
+			return meta
+		}
+	}
+	return nil
+}
+
+// newOperation creates the operation of a route with the given path parameters and metadata.
+func newOperation(b *schemaBuilder, params []*Parameter, meta *Meta) *Operation {
+	op := &Operation{
+		Parameters: params,
+		Responses:  make(map[string]*Response),
+	}
+	if meta == nil {
+		op.Responses["200"] = &Response{Description: http.StatusText(http.StatusOK)}
+		return op
+	}
+	op.OperationID = meta.OperationID
+	op.Summary = meta.Summary
+	op.Description = meta.Description
+	op.Tags = meta.Tags
+	op.Deprecated = meta.Deprecated
+	if meta.Query != nil {
+		op.Parameters = append(op.Parameters, queryParams(b, reflect.TypeOf(meta.Query))...)
+	}
+	if meta.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{JSONType: {Schema: b.schemaOf(reflect.TypeOf(meta.Request))}},
+		}
+	}
+	for status, body := range meta.Responses {
+		res := &Response{Description: http.StatusText(status)}
+		if body != nil {
+			res.Content = map[string]*MediaType{JSONType: {Schema: b.schemaOf(reflect.TypeOf(body))}}
+		}
+		op.Responses[strconv.Itoa(status)] = res
+	}
+	if len(op.Responses) == 0 {
+		op.Responses["200"] = &Response{Description: http.StatusText(http.StatusOK)}
+	}
+	return op
+}
+
+// queryParams returns the query parameters described by the fields of the struct type.
+func queryParams(b *schemaBuilder, t reflect.Type) []*Parameter {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var params []*Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("form")
+		if field.PkgPath != "" || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		params = append(params, &Parameter{
+			Name:   name,
+			In:     "query",
+			Schema: b.schemaOf(field.Type),
+		})
+	}
+	return params
+}
+
+// convertPath converts a route pattern into an OpenAPI path and its path parameters.
+// False is returned if the pattern cannot be described by OpenAPI.
+func convertPath(pattern string) (string, []*Parameter, bool) {
+	if strings.HasSuffix(pattern, "*") {
+		return "", nil, false
+	}
+	var (
+		path   string
+		params []*Parameter
+	)
+	start, end := -1, 0
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == '<' && start < 0 {
+			start = i
+		} else if pattern[i] == '>' && start >= 0 {
+			name, typ := pattern[start+1:i], ""
+			if j := strings.IndexByte(name, ':'); j >= 0 {
+				name, typ = name[:j], name[j+1:]
+			}
+			if typ == ".*" {
+				return "", nil, false
+			}
+			if name == "" {
+				name = "param" + strconv.Itoa(len(params)+1)
+			}
+			path += pattern[end:start] + "{" + name + "}"
+			params = append(params, &Parameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   paramSchema(typ),
+			})
+			start, end = -1, i+1
+		}
+	}
+	return path + pattern[end:], params, true
+}
+
+// paramSchema returns the schema of a path parameter with the given type or regular expression.
+func paramSchema(typ string) *Schema {
+	if typ == "" {
+		return &Schema{Type: "string"}
+	}
+	if s, ok := ParamTypes[typ]; ok {
+		schema := *s
+		return &schema
+	}
+	return &Schema{Type: "string", Pattern: "^(?:" + typ + ")$"}
+}
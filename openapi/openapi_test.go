@@ -0,0 +1,125 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ltick/tick-routing"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type userQuery struct {
+	Page int    `form:"page"`
+	Sort string `form:"sort"`
+}
+
+func handler(c *routing.Context) error {
+	return nil
+}
+
+func TestConvertPath(t *testing.T) {
+	path, params, ok := convertPath(`/users/<id:\d+>/items/<name>`)
+	assert.True(t, ok)
+	assert.Equal(t, "/users/{id}/items/{name}", path)
+	if assert.Len(t, params, 2) {
+		assert.Equal(t, &Parameter{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string", Pattern: `^(?:\d+)$`}}, params[0])
+		assert.Equal(t, &Parameter{Name: "name", In: "path", Required: true, Schema: &Schema{Type: "string"}}, params[1])
+	}
+
+	path, params, ok = convertPath("/users/<id:int>/<:slug>")
+	assert.True(t, ok)
+	assert.Equal(t, "/users/{id}/{param2}", path)
+	if assert.Len(t, params, 2) {
+		assert.Equal(t, &Schema{Type: "integer", Format: "int64"}, params[0].Schema)
+		assert.Equal(t, "^[a-z0-9]+(-[a-z0-9]+)*$", params[1].Schema.Pattern)
+	}
+
+	_, _, ok = convertPath("/files/*")
+	assert.False(t, ok)
+	_, _, ok = convertPath("/files/<path:.*>")
+	assert.False(t, ok)
+}
+
+func TestGenerate(t *testing.T) {
+	r := routing.New()
+	r.Get("/users", handler).Tag(Meta{
+		Summary:   "List users",
+		Query:     userQuery{},
+		Responses: map[int]interface{}{200: []user{}},
+	})
+	r.Post("/users", handler).Tag(&Meta{
+		OperationID: "createUser",
+		Request:     user{},
+		Responses:   map[int]interface{}{201: user{}, 400: nil},
+	})
+	r.Get("/users/<id:int>", handler)
+	r.Get("/files/*", handler)
+
+	doc := Generate(r, Info{Title: "Test", Version: "1.0"})
+	assert.Equal(t, Version, doc.OpenAPI)
+	assert.Equal(t, "Test", doc.Info.Title)
+	assert.Len(t, doc.Paths, 2)
+
+	list := doc.Paths["/users"]["get"]
+	if assert.NotNil(t, list) {
+		assert.Equal(t, "List users", list.Summary)
+		if assert.Len(t, list.Parameters, 2) {
+			assert.Equal(t, "page", list.Parameters[0].Name)
+			assert.Equal(t, "query", list.Parameters[0].In)
+		}
+		assert.Equal(t, &Schema{Type: "array", Items: &Schema{Ref: "#/components/schemas/user"}}, list.Responses["200"].Content[JSONType].Schema)
+	}
+
+	create := doc.Paths["/users"]["post"]
+	if assert.NotNil(t, create) {
+		assert.Equal(t, "createUser", create.OperationID)
+		assert.Equal(t, &Schema{Ref: "#/components/schemas/user"}, create.RequestBody.Content[JSONType].Schema)
+		assert.Equal(t, "Created", create.Responses["201"].Description)
+		assert.Nil(t, create.Responses["400"].Content)
+	}
+
+	get := doc.Paths["/users/{id}"]["get"]
+	if assert.NotNil(t, get) {
+		assert.Len(t, get.Parameters, 1)
+		assert.Equal(t, "OK", get.Responses["200"].Description)
+	}
+
+	assert.Equal(t, []string{"id", "name"}, doc.Components.Schemas["user"].Required)
+}
+
+func TestHandler(t *testing.T) {
+	r := routing.New()
+	h := Handler(r, Info{Title: "Test", Version: "1.0"})
+	r.Get("/openapi.json", h)
+	r.Get("/openapi.yaml", h)
+	r.Get("/users/<id>", handler)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, JSONType, res.Header().Get("Content-Type"))
+	var doc map[string]interface{}
+	assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &doc))
+	assert.Contains(t, doc["paths"], "/users/{id}")
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/openapi.yaml", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, "application/yaml", res.Header().Get("Content-Type"))
+	doc = nil
+	assert.Nil(t, yaml.Unmarshal(res.Body.Bytes(), &doc))
+	assert.Equal(t, Version, doc["openapi"])
+	assert.Contains(t, doc["paths"], "/users/{id}")
+}
@@ -0,0 +1,62 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	base struct {
+		Created time.Time `json:"created"`
+	}
+
+	node struct {
+		base
+		Name     string            `json:"name"`
+		Note     *string           `json:"note"`
+		Score    float64           `json:"score,omitempty"`
+		Labels   map[string]string `json:"labels,omitempty"`
+		Children []node            `json:"children,omitempty"`
+		Parent   *node             `json:"parent,omitempty"`
+		Data     []byte            `json:"data,omitempty"`
+		Ignored  string            `json:"-"`
+		private  string
+	}
+)
+
+func TestSchemaOf(t *testing.T) {
+	schemas := make(map[string]*Schema)
+	b := newSchemaBuilder(schemas)
+
+	assert.Equal(t, &Schema{Type: "boolean"}, b.schemaOf(reflect.TypeOf(true)))
+	assert.Equal(t, &Schema{Type: "integer", Format: "int32"}, b.schemaOf(reflect.TypeOf(int32(1))))
+	assert.Equal(t, &Schema{Type: "integer", Minimum: float(0)}, b.schemaOf(reflect.TypeOf(uint(1))))
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Type: "string"}}, b.schemaOf(reflect.TypeOf([]string{})))
+	assert.Equal(t, &Schema{}, b.schemaOf(reflect.TypeOf((*interface{})(nil)).Elem()))
+	assert.Equal(t, &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"A": {Type: "string"}},
+		Required:   []string{"A"},
+	}, b.schemaOf(reflect.TypeOf(struct{ A string }{})))
+
+	assert.Equal(t, &Schema{Ref: "#/components/schemas/node"}, b.schemaOf(reflect.TypeOf(&node{})))
+	s := schemas["node"]
+	if assert.NotNil(t, s) {
+		assert.Equal(t, []string{"created", "name"}, s.Required)
+		assert.Len(t, s.Properties, 8)
+		assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, s.Properties["created"])
+		assert.Equal(t, &Schema{Type: "string", Nullable: true}, s.Properties["note"])
+		assert.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}}, s.Properties["labels"])
+		assert.Equal(t, &Schema{Type: "array", Items: &Schema{Ref: "#/components/schemas/node"}}, s.Properties["children"])
+		assert.Equal(t, &Schema{Ref: "#/components/schemas/node"}, s.Properties["parent"])
+		assert.Equal(t, &Schema{Type: "string", Format: "byte"}, s.Properties["data"])
+	}
+	assert.Len(t, schemas, 1)
+}
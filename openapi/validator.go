@@ -0,0 +1,412 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ltick/tick-routing"
+)
+
+type (
+	// ValidationError is returned by the handler created by Validator when a request does not conform to the OpenAPI document.
+	// It responds with a 400 HTTP status and lists every invalid part of the request.
+	ValidationError struct {
+		Status  int          `json:"status" xml:"status"`
+		Message string       `json:"message" xml:"message"`
+		Errors  []FieldError `json:"errors" xml:"errors>error"`
+	}
+
+	// FieldError describes an invalid part of a request.
+	FieldError struct {
+		In      string `json:"in" xml:"in"`           // "path", "query", "header" or "body"
+		Name    string `json:"name" xml:"name"`       // the parameter name, or the JSON pointer of the invalid part of the body
+		Message string `json:"message" xml:"message"` // the reason why the part is invalid
+	}
+
+	// ValidatorOptions represents the options that can be used with the Validator handler.
+	ValidatorOptions struct {
+		// the maximum size of a request body in bytes. A larger body results in a 413 HTTP error.
+		// Defaults to 0, which means no limit.
+		MaxBodySize int64
+	}
+
+	// validator validates requests against the operations of an OpenAPI document.
+	validator struct {
+		doc      *Document
+		options  ValidatorOptions
+		patterns sync.Map // compiled regular expressions indexed by schema patterns
+	}
+)
+
+// Error returns the error message listing the invalid parts of the request.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.String()
+	}
+	return e.Message + ": " + strings.Join(msgs, "; ")
+}
+
+// StatusCode returns the HTTP status code of the error.
+func (e *ValidationError) StatusCode() int {
+	return e.Status
+}
+
+// Problem returns the problem details describing the error, with the invalid parts of the request
+// listed in the "errors" member.
+func (e *ValidationError) Problem() *routing.Problem {
+	return routing.NewProblem(e.Status, e.Message).With("errors", e.Errors)
+}
+
+// String returns the string representation of the field error.
+func (e FieldError) String() string {
+	if e.Name == "" {
+		return e.In + " " + e.Message
+	}
+	return e.In + " " + e.Name + " " + e.Message
+}
+
+// Validator returns a handler that validates requests against the operations described in the OpenAPI document,
+// which may be loaded from a file via Load. For example,
+//
+//	doc, err := openapi.Load("openapi.yaml")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	router.Use(openapi.Validator(doc))
+//
+// The operation of a request is found by converting the pattern of the route matching the request into an OpenAPI path
+// in the same way as Generate. Requests whose routes or operations are not described in the document are not validated.
+//
+// Path, query and header parameters are validated against their schemas, and JSON request bodies against the schema
+// of the "application/json" media type. The request body is restored after being read so that the following handlers
+// can read it again. The size of the request body may be limited via ValidatorOptions.MaxBodySize.
+//
+// If the request is invalid, the handler responds with a 400 HTTP status and the problem details returned by
+// ValidationError.Problem, written via routing.Context.WriteProblem in JSON or XML according to the data writer
+// of the request. The *ValidationError is then returned so that it can be logged, and the following handlers are skipped.
+func Validator(doc *Document, opts ...ValidatorOptions) routing.Handler {
+	v := &validator{doc: doc}
+	if len(opts) > 0 {
+		v.options = opts[0]
+	}
+	return func(c *routing.Context) error {
+		route := c.Route()
+		if route == nil {
+			return nil
+		}
+		path, _, ok := convertPath(route.Path())
+		if !ok {
+			return nil
+		}
+		op := doc.Paths[path][strings.ToLower(c.Request.Method)]
+		if op == nil {
+			return nil
+		}
+		errs := v.validateParams(c, op)
+		if op.RequestBody != nil {
+			bodyErrs, err := v.validateBody(c, op.RequestBody)
+			if err != nil {
+				return err
+			}
+			errs = append(errs, bodyErrs...)
+		}
+		if len(errs) == 0 {
+			return nil
+		}
+		err := &ValidationError{
+			Status:  http.StatusBadRequest,
+			Message: "invalid request",
+			Errors:  errs,
+		}
+		if c.ResponseWriter != nil {
+			c.WriteProblem(err.Problem())
+		}
+		return err
+	}
+}
+
+// validateParams validates the path, query and header parameters of the request.
+func (v *validator) validateParams(c *routing.Context, op *Operation) []FieldError {
+	var errs []FieldError
+	query := c.Request.URL.Query()
+	for _, p := range op.Parameters {
+		var values []string
+		switch p.In {
+		case "path":
+			values = []string{c.Param(p.Name)}
+		case "query":
+			values = query[p.Name]
+		case "header":
+			values = c.Request.Header[http.CanonicalHeaderKey(p.Name)]
+		default:
+			continue
+		}
+		if len(values) == 0 {
+			if p.Required {
+				errs = append(errs, FieldError{p.In, p.Name, "is required"})
+			}
+			continue
+		}
+		if p.Schema == nil {
+			continue
+		}
+		schema := v.resolve(p.Schema)
+		if schema.Type == "array" && schema.Items != nil {
+			items := make([]interface{}, len(values))
+			for i, value := range values {
+				items[i] = v.convertParam(v.resolve(schema.Items), value)
+			}
+			v.validateValue(schema, items, p.In, p.Name, &errs)
+		} else {
+			v.validateValue(schema, v.convertParam(schema, values[0]), p.In, p.Name, &errs)
+		}
+	}
+	return errs
+}
+
+// convertParam converts a parameter value into the value of the JSON type of the schema,
+// so that it can be validated in the same way as the values in the request body.
+// The value is returned unchanged if it cannot be converted.
+func (v *validator) convertParam(schema *Schema, value string) interface{} {
+	switch schema.Type {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return json.Number(value)
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// validateBody validates the request body if the media type of the request is described in the request body.
+// An error is returned if the body exceeds the size limit of the validator.
+func (v *validator) validateBody(c *routing.Context, body *RequestBody) ([]FieldError, error) {
+	req := c.Request
+	var data []byte
+	if req.Body != nil {
+		reader := req.Body
+		if v.options.MaxBodySize > 0 {
+			reader = http.MaxBytesReader(c.ResponseWriter, req.Body, v.options.MaxBodySize)
+		}
+		var err error
+		if data, err = ioutil.ReadAll(reader); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return nil, routing.NewHTTPError(http.StatusRequestEntityTooLarge)
+			}
+			return []FieldError{{"body", "", "cannot be read"}}, nil
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	}
+	if len(data) == 0 {
+		if body.Required {
+			return []FieldError{{"body", "", "is required"}}, nil
+		}
+		return nil, nil
+	}
+
+	contentType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	media := body.Content[contentType]
+	if media == nil {
+		return []FieldError{{"header", "Content-Type", fmt.Sprintf("%q is not supported", contentType)}}, nil
+	}
+	if contentType != JSONType || media.Schema == nil {
+		return nil, nil
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return []FieldError{{"body", "", "must be valid JSON"}}, nil
+	}
+	var errs []FieldError
+	v.validateValue(media.Schema, value, "body", "", &errs)
+	return errs, nil
+}
+
+// validateValue validates a JSON value against the schema and appends the errors found to errs.
+// Object properties and array items are validated recursively, using JSON pointers as their names.
+func (v *validator) validateValue(schema *Schema, value interface{}, in, name string, errs *[]FieldError) {
+	schema = v.resolve(schema)
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, FieldError{in, name, fmt.Sprintf(format, args...)})
+	}
+	if value == nil {
+		if !schema.Nullable && schema.Type != "" {
+			fail("must not be null")
+		}
+		return
+	}
+
+	switch schema.Type {
+	case "object":
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		for _, key := range schema.Required {
+			if _, ok := m[key]; !ok {
+				*errs = append(*errs, FieldError{in, name + "/" + key, "is required"})
+			}
+		}
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if s := schema.Properties[key]; s != nil {
+				v.validateValue(s, m[key], in, name+"/"+key, errs)
+			} else if schema.AdditionalProperties != nil {
+				v.validateValue(schema.AdditionalProperties, m[key], in, name+"/"+key, errs)
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			fail("must be an array")
+			return
+		}
+		if schema.Items != nil {
+			for i, item := range items {
+				v.validateValue(schema.Items, item, in, name+"/"+strconv.Itoa(i), errs)
+			}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			fail("must be a string")
+			return
+		}
+		if msg := v.validateString(schema, s); msg != "" {
+			fail(msg)
+			return
+		}
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			fail("must be a number")
+			return
+		}
+		f, err := n.Float64()
+		if err != nil {
+			fail("must be a number")
+			return
+		}
+		if schema.Type == "integer" {
+			if _, err := n.Int64(); err != nil {
+				fail("must be an integer")
+				return
+			}
+		}
+		if schema.Minimum != nil && f < *schema.Minimum {
+			fail("must be no less than %v", *schema.Minimum)
+			return
+		}
+		if schema.Maximum != nil && f > *schema.Maximum {
+			fail("must be no greater than %v", *schema.Maximum)
+			return
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("must be a boolean")
+			return
+		}
+	}
+
+	if len(schema.Enum) > 0 {
+		for _, e := range schema.Enum {
+			if fmt.Sprint(e) == fmt.Sprint(value) {
+				return
+			}
+		}
+		fail("must be one of %v", schema.Enum)
+	}
+}
+
+// validateString validates the string against the length, pattern and format of the schema.
+// It returns the error message, or an empty string if the string is valid.
+func (v *validator) validateString(schema *Schema, s string) string {
+	if schema.MinLength != nil && utf8.RuneCountInString(s) < *schema.MinLength {
+		return fmt.Sprintf("must be at least %v characters long", *schema.MinLength)
+	}
+	if schema.MaxLength != nil && utf8.RuneCountInString(s) > *schema.MaxLength {
+		return fmt.Sprintf("must be at most %v characters long", *schema.MaxLength)
+	}
+	if schema.Pattern != "" {
+		if re := v.compile(schema.Pattern); re != nil && !re.MatchString(s) {
+			return fmt.Sprintf("must match the pattern %q", schema.Pattern)
+		}
+	}
+	var err error
+	switch schema.Format {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, s)
+	case "date":
+		_, err = time.Parse("2006-01-02", s)
+	case "byte":
+		_, err = base64.StdEncoding.DecodeString(s)
+	case "uuid":
+		if !uuidPattern.MatchString(s) {
+			return "must be a valid uuid"
+		}
+	}
+	if err != nil {
+		return "must be a valid " + schema.Format
+	}
+	return ""
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// compile returns the compiled regular expression of the pattern, or nil if the pattern is invalid.
+func (v *validator) compile(pattern string) *regexp.Regexp {
+	if re, ok := v.patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil
+	}
+	v.patterns.Store(pattern, re)
+	return re
+}
+
+// resolve returns the component schema referenced by the schema, or the schema itself if it is not a reference.
+func (v *validator) resolve(schema *Schema) *Schema {
+	for i := 0; schema.Ref != "" && i < 32; i++ {
+		if v.doc.Components == nil {
+			break
+		}
+		s := v.doc.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+		if s == nil {
+			break
+		}
+		schema = s
+	}
+	return schema
+}
@@ -0,0 +1,137 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package openapi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ltick/tick-routing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	doc, err := Load("testdata/openapi.yaml")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "Users", doc.Info.Title)
+		assert.Len(t, doc.Paths["/users"], 1)
+		assert.NotNil(t, doc.Paths["/users/{id}"]["get"])
+		assert.Equal(t, []string{"name", "email"}, doc.Components.Schemas["User"].Required)
+	}
+
+	// a document in JSON
+	data, _ := json.Marshal(doc)
+	dir, _ := ioutil.TempDir("", "openapi")
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "openapi.json")
+	ioutil.WriteFile(file, data, 0644)
+	doc2, err := Load(file)
+	if assert.Nil(t, err) {
+		assert.Equal(t, doc, doc2)
+	}
+
+	_, err = Load("testdata/missing.yaml")
+	assert.NotNil(t, err)
+}
+
+func TestValidator(t *testing.T) {
+	doc, err := Load("testdata/openapi.yaml")
+	if !assert.Nil(t, err) {
+		return
+	}
+	r := routing.New()
+	r.Use(Validator(doc))
+	r.Post("/users", func(c *routing.Context) error {
+		data, _ := ioutil.ReadAll(c.Request.Body)
+		return c.Write("created " + string(data))
+	})
+	r.Get("/users/<id>", func(c *routing.Context) error {
+		return c.Write("user " + c.Param("id"))
+	})
+	r.Put("/users/<id>", func(c *routing.Context) error {
+		return c.Write("updated")
+	})
+
+	tests := []struct {
+		method, url, body string
+		header            http.Header
+		status            int
+		response          string
+	}{
+		{"GET", "/users/3?fields=name&fields=email&verbose=true", "", nil, http.StatusOK, "user 3"},
+		{"GET", "/users/abc", "", nil, http.StatusBadRequest, "invalid request: path id must be a number\n"},
+		{"GET", "/users/0?fields=phone&verbose=yes", "", nil, http.StatusBadRequest, "invalid request: path id must be no less than 1; query fields/0 must be one of [name email]; query verbose must be a boolean\n"},
+		{"PUT", "/users/abc", "", nil, http.StatusOK, "updated"},
+		{"POST", "/users", `{"name":"John","email":"john@example.com","age":30}`,
+			http.Header{"Content-Type": {"application/json"}, "X-Request-Id": {"0b9e2c9e-6d4f-4a8e-9a43-3b5b1f6f5d3a"}},
+			http.StatusOK, `created {"name":"John","email":"john@example.com","age":30}`},
+		{"POST", "/users", `{"name":"J","age":-1,"tags":["a",1]}`,
+			http.Header{"Content-Type": {"application/json"}},
+			http.StatusBadRequest, "invalid request: header X-Request-Id is required; body /email is required; body /age must be no less than 0; body /name must be at least 2 characters long; body /tags/1 must be a string\n"},
+		{"POST", "/users", `{"name":`, http.Header{"Content-Type": {"application/json"}, "X-Request-Id": {"bad"}},
+			http.StatusBadRequest, "invalid request: header X-Request-Id must be a valid uuid; body must be valid JSON\n"},
+		{"POST", "/users", "", http.Header{"X-Request-Id": {"0b9e2c9e-6d4f-4a8e-9a43-3b5b1f6f5d3a"}},
+			http.StatusBadRequest, "invalid request: body is required\n"},
+		{"POST", "/users", "name=John", http.Header{"Content-Type": {"application/x-www-form-urlencoded"}, "X-Request-Id": {"0b9e2c9e-6d4f-4a8e-9a43-3b5b1f6f5d3a"}},
+			http.StatusBadRequest, "invalid request: header Content-Type \"application/x-www-form-urlencoded\" is not supported\n"},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest(test.method, test.url, strings.NewReader(test.body))
+		for name, values := range test.header {
+			req.Header[name] = values
+		}
+		r.ServeHTTP(res, req)
+		assert.Equal(t, test.status, res.Code, test.method+" "+test.url)
+		if test.status != http.StatusBadRequest {
+			assert.Equal(t, test.response, res.Body.String(), test.method+" "+test.url)
+			continue
+		}
+		// the validation errors are written as problem details
+		assert.Equal(t, routing.MIME_PROBLEM_JSON, res.Header().Get("Content-Type"), test.method+" "+test.url)
+		var problem struct {
+			Title  string       `json:"title"`
+			Detail string       `json:"detail"`
+			Errors []FieldError `json:"errors"`
+		}
+		if assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &problem), test.method+" "+test.url) {
+			assert.Equal(t, "Bad Request", problem.Title)
+			err := &ValidationError{Message: problem.Detail, Errors: problem.Errors}
+			assert.Equal(t, test.response, err.Error()+"\n", test.method+" "+test.url)
+		}
+	}
+
+	// the request body is limited
+	r = routing.New()
+	r.Use(Validator(doc, ValidatorOptions{MaxBodySize: 10}))
+	r.Post("/users", func(c *routing.Context) error {
+		return c.Write("created")
+	})
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/users", strings.NewReader(`{"name":"John","email":"john@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-Id", "0b9e2c9e-6d4f-4a8e-9a43-3b5b1f6f5d3a")
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+}
+
+func TestValidationError(t *testing.T) {
+	err := &ValidationError{
+		Status:  http.StatusBadRequest,
+		Message: "invalid request",
+		Errors:  []FieldError{{"query", "page", "must be a number"}},
+	}
+	assert.Equal(t, http.StatusBadRequest, err.StatusCode())
+	data, _ := json.Marshal(err)
+	assert.Equal(t, `{"status":400,"message":"invalid request","errors":[{"in":"query","name":"page","message":"must be a number"}]}`, string(data))
+	data, _ = json.Marshal(err.Problem())
+	assert.Equal(t, `{"title":"Bad Request","status":400,"detail":"invalid request","errors":[{"in":"query","name":"page","message":"must be a number"}]}`, string(data))
+}
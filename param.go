@@ -0,0 +1,102 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import "time"
+
+// ParamMatcher matches a typed route parameter against the beginning of the given path.
+// It returns the number of bytes that belong to the parameter value, or 0 if the path
+// does not start with a valid value.
+type ParamMatcher func(path string) int
+
+// paramMatchers lists the typed parameter tokens that can be used in route patterns, such as "<id:int>".
+var paramMatchers = map[string]ParamMatcher{
+	"int":  matchInt,
+	"uint": matchUint,
+	"uuid": matchUUID,
+	"slug": matchSlug,
+	"date": matchDate,
+}
+
+// RegisterParamType registers a typed parameter matcher under the given name.
+// Once registered, route patterns may use the "<param:name>" token to match parameters with the matcher
+// instead of a regular expression. A registered name takes precedence over a regular expression with the same text.
+// RegisterParamType should be called before any route using the type is added. It is not thread safe.
+func RegisterParamType(name string, matcher ParamMatcher) {
+	paramMatchers[name] = matcher
+}
+
+// matchInt matches an optionally signed decimal integer.
+func matchInt(path string) int {
+	i := 0
+	if len(path) > 0 && (path[0] == '-' || path[0] == '+') {
+		i++
+	}
+	if n := matchUint(path[i:]); n > 0 {
+		return i + n
+	}
+	return 0
+}
+
+// matchUint matches an unsigned decimal integer.
+func matchUint(path string) int {
+	i := 0
+	for ; i < len(path) && isDigit(path[i]); i++ {
+	}
+	return i
+}
+
+// matchUUID matches a UUID in its canonical 8-4-4-4-12 hexadecimal form.
+func matchUUID(path string) int {
+	if len(path) < 36 {
+		return 0
+	}
+	for i := 0; i < 36; i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if path[i] != '-' {
+				return 0
+			}
+		default:
+			if !isHex(path[i]) {
+				return 0
+			}
+		}
+	}
+	return 36
+}
+
+// matchSlug matches lowercase letters and digits, optionally separated by single hyphens.
+func matchSlug(path string) int {
+	i, last := 0, 0
+	for ; i < len(path); i++ {
+		c := path[i]
+		if c >= 'a' && c <= 'z' || isDigit(c) {
+			last = i + 1
+		} else if c != '-' || i == 0 || path[i-1] == '-' {
+			break
+		}
+	}
+	return last
+}
+
+// matchDate matches a calendar date in the format of "YYYY-MM-DD".
+func matchDate(path string) int {
+	if len(path) < 10 {
+		return 0
+	}
+	if _, err := time.Parse("2006-01-02", path[:10]); err != nil {
+		return 0
+	}
+	return 10
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHex(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
@@ -0,0 +1,91 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParamMatchers(t *testing.T) {
+	tests := []struct {
+		id       string
+		matcher  ParamMatcher
+		path     string
+		expected int
+	}{
+		{"int", matchInt, "123/abc", 3},
+		{"signed int", matchInt, "-123", 4},
+		{"sign only", matchInt, "-", 0},
+		{"not int", matchInt, "abc", 0},
+		{"uint", matchUint, "42", 2},
+		{"negative uint", matchUint, "-42", 0},
+		{"uuid", matchUUID, "0B7E5E58-6D5C-4B0F-9D24-5F2B8A3F1C2D/x", 36},
+		{"short uuid", matchUUID, "0b7e5e58-6d5c-4b0f-9d24", 0},
+		{"bad uuid", matchUUID, "0b7e5e58_6d5c-4b0f-9d24-5f2b8a3f1c2d", 0},
+		{"slug", matchSlug, "hello-world-2/x", 13},
+		{"slug trailing hyphen", matchSlug, "hello-", 5},
+		{"slug leading hyphen", matchSlug, "-hello", 0},
+		{"date", matchDate, "2016-12-31/x", 10},
+		{"bad date", matchDate, "2016-13-01", 0},
+		{"short date", matchDate, "2016-12", 0},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.matcher(test.path), test.id)
+	}
+}
+
+func TestRegisterParamType(t *testing.T) {
+	RegisterParamType("hex", func(path string) int {
+		i := 0
+		for ; i < len(path) && isHex(path[i]); i++ {
+		}
+		return i
+	})
+	defer delete(paramMatchers, "hex")
+
+	h := newStore()
+	h.Add("/colors/<c:hex>", "1")
+	pvalues := make([]string, 1)
+	data, pnames := h.Get("/colors/ff00aa", pvalues)
+	assert.Equal(t, "1", data)
+	assert.Equal(t, []string{"c"}, pnames)
+	assert.Equal(t, "ff00aa", pvalues[0])
+	data, _ = h.Get("/colors/xyz", pvalues)
+	assert.Nil(t, data)
+}
+
+func TestContextTypedParams(t *testing.T) {
+	c := NewContext(nil, nil)
+	c.pnames = []string{"id", "uuid", "d", "neg"}
+	c.pvalues = []string{"123", "0B7E5E58-6D5C-4B0F-9D24-5F2B8A3F1C2D", "2016-02-29", "-1"}
+
+	id, err := c.ParamInt("id")
+	assert.Nil(t, err)
+	assert.Equal(t, 123, id)
+	n, err := c.ParamUint("id")
+	assert.Nil(t, err)
+	assert.Equal(t, uint(123), n)
+	uuid, err := c.ParamUUID("uuid")
+	assert.Nil(t, err)
+	assert.Equal(t, "0b7e5e58-6d5c-4b0f-9d24-5f2b8a3f1c2d", uuid)
+	d, err := c.ParamDate("d")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2016, 2, 29, 0, 0, 0, 0, time.UTC), d)
+
+	_, err = c.ParamInt("unknown")
+	assert.NotNil(t, err)
+	_, err = c.ParamUint("neg")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusBadRequest, err.(HTTPError).StatusCode())
+	}
+	_, err = c.ParamUUID("id")
+	assert.NotNil(t, err)
+	_, err = c.ParamDate("id")
+	assert.NotNil(t, err)
+}
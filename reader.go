@@ -1,13 +1,20 @@
 package routing
 
 import (
+	"bytes"
 	"encoding"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // MIME types used when doing request data reading and response data writing.
@@ -18,10 +25,22 @@ const (
 	MIME_HTML           = "text/html"
 	MIME_FORM           = "application/x-www-form-urlencoded"
 	MIME_MULTIPART_FORM = "multipart/form-data"
+	MIME_YAML           = "application/yaml"
+	MIME_YAML2          = "application/x-yaml"
+	MIME_MSGPACK        = "application/msgpack"
+	MIME_MSGPACK2       = "application/x-msgpack"
+	MIME_PROTOBUF       = "application/protobuf"
+	MIME_PROTOBUF2      = "application/x-protobuf"
+	MIME_CBOR           = "application/cbor"
+	MIME_PROBLEM_JSON   = "application/problem+json"
+	MIME_PROBLEM_XML    = "application/problem+xml"
 )
 
 var (
 	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeadersType     = reflect.TypeOf([]*multipart.FileHeader(nil))
 )
 
 // DataReader is used by Context.Read() to read data from an HTTP request.
@@ -48,10 +67,131 @@ var (
 )
 
 // JSONDataReader reads the request body as JSON-formatted data.
-type JSONDataReader struct{}
+// By default, the body size is not limited, and unknown fields and data following the JSON value are ignored.
+// The options can be changed by registering a configured reader in DataReaders, for example,
+//
+//	routing.DataReaders[routing.MIME_JSON] = &routing.JSONDataReader{
+//		MaxBodySize:           1 << 20,
+//		DisallowUnknownFields: true,
+//		DisallowTrailingData:  true,
+//	}
+type JSONDataReader struct {
+	// the maximum number of bytes in the request body. A larger body results in a 413 HTTP error. Zero means no limit.
+	MaxBodySize int64
+	// whether to return an error if the JSON object contains a field that does not match any field of the struct
+	DisallowUnknownFields bool
+	// whether to return an error if the JSON value is followed by data other than white spaces
+	DisallowTrailingData bool
+	// whether to decode numbers into interface{} values as json.Number instead of float64
+	UseNumber bool
+}
 
+// Read reads the JSON value in the request body into the data. Invalid JSON data results in a *ReadError,
+// which describes the position of the problem and responds with a 400 HTTP status.
+// An empty body results in io.EOF.
 func (r *JSONDataReader) Read(req *http.Request, data interface{}) error {
-	return json.NewDecoder(req.Body).Decode(data)
+	if r.MaxBodySize > 0 && req.ContentLength > r.MaxBodySize {
+		return errBodyTooLarge
+	}
+	var body io.Reader = req.Body
+	if r.MaxBodySize > 0 {
+		body = &limitedReader{req.Body, r.MaxBodySize}
+	}
+	decoder := json.NewDecoder(body)
+	if r.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if r.UseNumber {
+		decoder.UseNumber()
+	}
+
+	if err := decoder.Decode(data); err != nil {
+		return translateJSONError(err, decoder.InputOffset())
+	}
+	if r.DisallowTrailingData {
+		if _, err := decoder.Token(); err != io.EOF {
+			if err == errBodyTooLarge {
+				return err
+			}
+			return &ReadError{Status: http.StatusBadRequest, Message: "unexpected data after the JSON value", Offset: decoder.InputOffset()}
+		}
+	}
+	return nil
+}
+
+// ReadError describes request data that cannot be read. It implements HTTPError with the 400 HTTP status.
+type ReadError struct {
+	Status  int    `json:"status" xml:"status"`
+	Message string `json:"message" xml:"message"`
+	Field   string `json:"field,omitempty" xml:"field,omitempty"` // the path of the field whose value is invalid, if known
+	Offset  int64  `json:"offset" xml:"offset"`                   // the offset in the request body where the problem is found
+}
+
+// Error returns the error message including the field and the offset.
+func (e *ReadError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("%v (field %q, offset %v)", e.Message, e.Field, e.Offset)
+	}
+	return fmt.Sprintf("%v (offset %v)", e.Message, e.Offset)
+}
+
+// StatusCode returns the HTTP status code.
+func (e *ReadError) StatusCode() int {
+	return e.Status
+}
+
+// translateJSONError converts an error returned by the JSON decoder into an HTTP error.
+// The offset is used when the error does not report its own offset.
+func translateJSONError(err error, offset int64) error {
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		return &ReadError{Status: http.StatusBadRequest, Message: "invalid JSON: " + e.Error(), Offset: e.Offset}
+	case *json.UnmarshalTypeError:
+		return &ReadError{
+			Status:  http.StatusBadRequest,
+			Message: fmt.Sprintf("cannot use JSON %v as %v", e.Value, e.Type),
+			Field:   e.Field,
+			Offset:  e.Offset,
+		}
+	}
+	switch {
+	case err == io.ErrUnexpectedEOF:
+		return &ReadError{Status: http.StatusBadRequest, Message: "unexpected end of JSON input", Offset: offset}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		return &ReadError{Status: http.StatusBadRequest, Message: "unknown field", Field: field, Offset: offset}
+	}
+	return err
+}
+
+// errBodyTooLarge is returned by limitedReader when the request body exceeds the limit.
+var errBodyTooLarge = NewHTTPError(http.StatusRequestEntityTooLarge)
+
+// limitedReader reads at most n bytes from the underlying reader and returns errBodyTooLarge
+// if more data is available.
+type limitedReader struct {
+	r io.ReadCloser
+	n int64
+}
+
+func (l *limitedReader) Close() error {
+	return l.r.Close()
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, errBodyTooLarge
+	}
+	// read one more byte than allowed to detect if the body exceeds the limit
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n - 1, errBodyTooLarge
+	}
+	return n, err
 }
 
 // XMLDataReader reads the request body as XML-formatted data.
@@ -66,14 +206,98 @@ type FormDataReader struct{}
 
 func (r *FormDataReader) Read(req *http.Request, data interface{}) error {
 	// Do not check return result. Otherwise GET request will cause problem.
-	req.ParseMultipartForm(32 << 20)
-	return ReadFormData(req.Form, data)
+	req.ParseMultipartForm(DefaultMaxMemory)
+	var files map[string][]*multipart.FileHeader
+	if req.MultipartForm != nil {
+		files = req.MultipartForm.File
+	}
+	return readFormData(req.Form, files, data)
+}
+
+// FormConverter converts a form value into a value of the type it is registered for via RegisterFormConverter.
+// The returned value may also be a pointer to a value of the type.
+type FormConverter func(value string) (interface{}, error)
+
+// formConverters lists the converters used to populate form fields of specific types.
+var formConverters = map[reflect.Type]FormConverter{
+	reflect.TypeOf(time.Duration(0)): convertDuration,
 }
 
-const formTag = "form"
+// RegisterFormConverter registers the converter used to populate the form fields of the given type, for example,
+//
+//	routing.RegisterFormConverter(reflect.TypeOf(decimal.Decimal{}), func(value string) (interface{}, error) {
+//		return decimal.NewFromString(value)
+//	})
+//
+// The type should not be a pointer type, as pointer fields are populated with the values they point to.
+// A registered converter takes precedence over encoding.TextUnmarshaler and the built-in conversions,
+// and is also used for slice and map items and by Context.Bind. A converter for time.Duration is registered by default.
+// RegisterFormConverter should be called before any form data is read. It is not thread safe.
+func RegisterFormConverter(t reflect.Type, converter FormConverter) {
+	formConverters[t] = converter
+}
+
+// convertDuration converts a form value such as "1h30m" into a time.Duration. An empty value is converted into 0.
+func convertDuration(value string) (interface{}, error) {
+	if value == "" {
+		return time.Duration(0), nil
+	}
+	return time.ParseDuration(value)
+}
+
+// FormFieldError describes a form value that cannot be converted into the type of the corresponding struct field.
+// It implements HTTPError with the 400 HTTP status.
+type FormFieldError struct {
+	Key   string // the name of the form field, such as "items[0].count"
+	Value string // the form value
+	Err   error  // the conversion error
+}
+
+// Error returns the error message.
+func (e *FormFieldError) Error() string {
+	return fmt.Sprintf("invalid value %q for form field %q: %v", e.Value, e.Key, e.Err)
+}
+
+// StatusCode returns the HTTP status code.
+func (e *FormFieldError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+const (
+	formTag    = "form"
+	layoutTag  = "layout"
+	defaultTag = "default"
+
+	// maxFormIndex is the largest slice index accepted in form field names, such as "items[0].name".
+	maxFormIndex = 1000
+)
 
 // ReadFormData populates the data variable with the data from the given form values.
-func ReadFormData(form map[string][]string, data interface{}) error {
+// The populated data is then validated using DefaultValidator.
+//
+// Fields are named after their "form" tags, or their names if there is no tag. The fields of nested structs
+// are named with dotted names, such as "user.name", or bracket notation, such as "user[name]".
+// Slices of structs and other values are populated from indexed names, such as "items[0].name" and "ids[0]",
+// and map fields with string keys from names such as "attrs.color" or "attrs[color]".
+// A time.Time field with a "layout" tag is parsed using the layout, such as `layout:"2006-01-02"`,
+// and a field with a "default" tag receives the tag value if the form does not contain the field.
+//
+// If the uploaded files of a multipart form are given, the fields of type *multipart.FileHeader
+// and []*multipart.FileHeader receive the files uploaded under their names.
+func ReadFormData(form map[string][]string, data interface{}, files ...map[string][]*multipart.FileHeader) error {
+	var fs map[string][]*multipart.FileHeader
+	if len(files) > 0 {
+		fs = files[0]
+	}
+	if err := readFormData(form, fs, data); err != nil {
+		return err
+	}
+	return validate(data)
+}
+
+// readFormData populates the data variable with the data from the given form values and uploaded files
+// without validating it.
+func readFormData(form map[string][]string, files map[string][]*multipart.FileHeader, data interface{}) error {
 	rv := reflect.ValueOf(data)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
 		return errors.New("data must be a pointer")
@@ -83,7 +307,56 @@ func ReadFormData(form map[string][]string, data interface{}) error {
 		return errors.New("data must be a pointer to a struct")
 	}
 
-	return readForm(form, "", rv)
+	if err := readForm(normalizeFormKeys(form), "", rv); err != nil {
+		return err
+	}
+	if len(files) > 0 {
+		readFormFiles(files, "", rv)
+	}
+	return nil
+}
+
+// normalizeFormKeys converts the bracket notation in form field names into dotted names, except for
+// numeric indexes. For example, "user[name]" becomes "user.name", "items[0][name]" becomes "items[0].name",
+// and "tags[]" becomes "tags".
+func normalizeFormKeys(form map[string][]string) map[string][]string {
+	var normalized map[string][]string
+	for key := range form {
+		if strings.Contains(key, "[") {
+			normalized = make(map[string][]string, len(form))
+			break
+		}
+	}
+	if normalized == nil {
+		return form
+	}
+	for key, values := range form {
+		name := normalizeFormKey(key)
+		normalized[name] = append(normalized[name], values...)
+	}
+	return normalized
+}
+
+// normalizeFormKey converts the bracket notation in a form field name into a dotted name.
+func normalizeFormKey(key string) string {
+	var buf bytes.Buffer
+	for {
+		i := strings.IndexByte(key, '[')
+		j := strings.IndexByte(key, ']')
+		if i < 0 || j < i {
+			buf.WriteString(key)
+			return buf.String()
+		}
+		buf.WriteString(key[:i])
+		if index := key[i+1 : j]; index != "" {
+			if _, err := strconv.Atoi(index); err == nil {
+				buf.WriteString(key[i : j+1])
+			} else {
+				buf.WriteString("." + index)
+			}
+		}
+		key = key[j+1:]
+	}
 }
 
 func readForm(form map[string][]string, prefix string, rv reflect.Value) error {
@@ -99,11 +372,6 @@ func readForm(form map[string][]string, prefix string, rv reflect.Value) error {
 			continue
 		}
 
-		ft := field.Type
-		if ft.Kind() == reflect.Ptr {
-			ft = ft.Elem()
-		}
-
 		name := tag
 		if name == "" && !field.Anonymous {
 			name = field.Name
@@ -112,47 +380,297 @@ func readForm(form map[string][]string, prefix string, rv reflect.Value) error {
 			name = prefix + "." + name
 		}
 
-		// check if type implements a known type, like encoding.TextUnmarshaler
-		if ok, err := readFormFieldKnownType(form, name, rv.Field(i)); err != nil {
+		if ok, err := readFormFieldValue(form, name, field, rv.Field(i)); err != nil {
 			return err
 		} else if ok {
 			continue
 		}
 
-		if ft.Kind() != reflect.Struct {
-			if err := readFormField(form, name, rv.Field(i)); err != nil {
-				return err
+		if name == "" {
+			name = prefix
+		}
+		if err := readForm(form, name, rv.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readFormFieldValue populates the struct field with the named form values. It returns false without populating
+// the field if the field is a struct that should be populated field by field.
+func readFormFieldValue(form map[string][]string, name string, field reflect.StructField, rv reflect.Value) (bool, error) {
+	if def, ok := field.Tag.Lookup(defaultTag); ok && !hasFormValue(form, name) {
+		// the default value is only used for this field
+		form = map[string][]string{name: {def}}
+	}
+
+	if field.Type == fileHeaderType || field.Type == fileHeadersType {
+		// uploaded files are populated by readFormFiles
+		return true, nil
+	}
+
+	ft := field.Type
+	if ft.Kind() == reflect.Ptr {
+		ft = ft.Elem()
+	}
+	if layout := field.Tag.Get(layoutTag); layout != "" && ft == timeType {
+		return true, readFormTime(form, name, layout, rv)
+	}
+
+	// check if type implements a known type, like encoding.TextUnmarshaler
+	if ok, err := readFormFieldKnownType(form, name, rv); err != nil || ok {
+		return true, err
+	}
+
+	switch ft.Kind() {
+	case reflect.Struct:
+		return false, nil
+	case reflect.Map:
+		return true, readFormMap(form, name, rv)
+	case reflect.Slice:
+		if _, ok := form[name]; !ok || isFormStruct(ft.Elem()) {
+			return true, readFormSlice(form, name, rv)
+		}
+	}
+	return true, readFormField(form, name, rv)
+}
+
+// readFormFiles populates the *multipart.FileHeader and []*multipart.FileHeader fields of the struct
+// with the files uploaded under their names. The fields are named in the same way as by readForm.
+func readFormFiles(files map[string][]*multipart.FileHeader, prefix string, rv reflect.Value) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag := field.Tag.Get(formTag)
+
+		// only handle anonymous or exported fields
+		if !field.Anonymous && field.PkgPath != "" || tag == "-" {
+			continue
+		}
+
+		name := tag
+		if name == "" && !field.Anonymous {
+			name = field.Name
+		}
+		if name != "" && prefix != "" {
+			name = prefix + "." + name
+		}
+
+		fv := rv.Field(i)
+		switch {
+		case field.Type == fileHeaderType:
+			if fhs := files[name]; len(fhs) > 0 && fv.CanSet() {
+				fv.Set(reflect.ValueOf(fhs[0]))
+			}
+		case field.Type == fileHeadersType:
+			if fhs := files[name]; len(fhs) > 0 && fv.CanSet() {
+				fv.Set(reflect.ValueOf(fhs))
+			}
+		default:
+			if fv.Kind() == reflect.Ptr && !fv.IsNil() {
+				fv = fv.Elem()
 			}
+			if fv.Kind() == reflect.Struct {
+				if name == "" {
+					name = prefix
+				}
+				readFormFiles(files, name, fv)
+			}
+		}
+	}
+}
+
+// hasFormValue checks if the form contains the named field, or any of its items or nested fields.
+func hasFormValue(form map[string][]string, name string) bool {
+	if _, ok := form[name]; ok {
+		return true
+	}
+	for key := range form {
+		if strings.HasPrefix(key, name) && len(key) > len(name) && (key[len(name)] == '.' || key[len(name)] == '[') {
+			return true
+		}
+	}
+	return false
+}
+
+// isFormStruct checks if the values of the type are populated field by field rather than from a single form value.
+// Structs with a converter registered via RegisterFormConverter or implementing encoding.TextUnmarshaler
+// are populated from a single form value.
+func isFormStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if _, ok := formConverters[t]; ok {
+		return false
+	}
+	return t.Kind() == reflect.Struct && !t.Implements(textUnmarshalerType) && !reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// readFormTime parses the named form value as a time using the layout. An empty value is ignored.
+func readFormTime(form map[string][]string, name, layout string, rv reflect.Value) error {
+	value, ok := form[name]
+	if !ok || value[0] == "" {
+		return nil
+	}
+	t, err := time.Parse(layout, value[0])
+	if err != nil {
+		return &FormFieldError{Key: name, Value: value[0], Err: err}
+	}
+	indirect(rv).Set(reflect.ValueOf(t))
+	return nil
+}
+
+// readFormSlice populates the slice from the indexed form values, such as "items[0].name" or "ids[0]".
+func readFormSlice(form map[string][]string, name string, rv reflect.Value) error {
+	n := -1
+	prefix := name + "["
+	for key := range form {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		rest := key[len(prefix):]
+		j := strings.IndexByte(rest, ']')
+		if j < 0 {
+			continue
+		}
+		index, err := strconv.Atoi(rest[:j])
+		if err != nil || j+1 < len(rest) && rest[j+1] != '.' && rest[j+1] != '[' {
 			continue
 		}
+		if index < 0 || index > maxFormIndex {
+			return fmt.Errorf("the index of %v exceeds %v", key, maxFormIndex)
+		}
+		if index >= n {
+			n = index + 1
+		}
+	}
+	if n < 0 {
+		return nil
+	}
 
-		if name == "" {
-			name = prefix
+	rv = indirect(rv)
+	slice := reflect.MakeSlice(rv.Type(), n, n)
+	for i := 0; i < n; i++ {
+		if err := readFormItem(form, fmt.Sprintf("%v[%v]", name, i), slice.Index(i)); err != nil {
+			return err
 		}
-		if err := readForm(form, name, rv.Field(i)); err != nil {
+	}
+	rv.Set(slice)
+	return nil
+}
+
+// readFormMap populates the map from the form values whose names start with the map name, such as "attrs.color".
+// The map must have string keys.
+func readFormMap(form map[string][]string, name string, rv reflect.Value) error {
+	rt := rv.Type()
+	if rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	structElem := isFormStruct(rt.Elem())
+	prefix := name + "."
+	var keys []string
+	found := make(map[string]bool)
+	for key := range form {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		key = key[len(prefix):]
+		if structElem {
+			if i := strings.IndexAny(key, ".["); i >= 0 {
+				key = key[:i]
+			}
+		}
+		if !found[key] {
+			found[key] = true
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	if rt.Key().Kind() != reflect.String {
+		return errors.New("Unsupported map key type: " + rt.Key().Kind().String())
+	}
+
+	rv = indirect(rv)
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMap(rt))
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		elem := reflect.New(rt.Elem()).Elem()
+		if err := readFormItem(form, prefix+key, elem); err != nil {
 			return err
 		}
+		rv.SetMapIndex(reflect.ValueOf(key).Convert(rt.Key()), elem)
 	}
 	return nil
 }
 
+// readFormItem populates a slice or map item with the named form values.
+func readFormItem(form map[string][]string, name string, rv reflect.Value) error {
+	if isFormStruct(rv.Type()) {
+		return readForm(form, name, rv)
+	}
+	if ok, err := readFormFieldKnownType(form, name, rv); err != nil || ok {
+		return err
+	}
+	return readFormField(form, name, rv)
+}
+
 func readFormFieldKnownType(form map[string][]string, name string, rv reflect.Value) (bool, error) {
 	value, ok := form[name]
 	if !ok {
 		return false, nil
 	}
-	rv = indirect(rv)
+	ok, err := setKnownTypeValue(indirect(rv), value[0])
+	if err != nil {
+		return true, &FormFieldError{Key: name, Value: value[0], Err: err}
+	}
+	return ok, nil
+}
+
+// setKnownTypeValue sets the value using the converter registered for the type via RegisterFormConverter,
+// or encoding.TextUnmarshaler if the type implements it. It returns false if the type is not a known type.
+func setKnownTypeValue(rv reflect.Value, value string) (bool, error) {
 	rt := rv.Type()
+	if converter, ok := formConverters[rt]; ok {
+		v, err := converter(value)
+		if err != nil {
+			return true, err
+		}
+		return true, setConvertedValue(rv, v)
+	}
 
 	// check if type implements encoding.TextUnmarshaler
 	if rt.Implements(textUnmarshalerType) {
-		return true, rv.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value[0]))
+		return true, rv.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
 	} else if reflect.PtrTo(rt).Implements(textUnmarshalerType) {
-		return true, rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value[0]))
+		return true, rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
 	}
 	return false, nil
 }
 
+// setConvertedValue sets the value returned by a form converter, which may also be a pointer to the value.
+func setConvertedValue(rv reflect.Value, v interface{}) error {
+	cv := reflect.ValueOf(v)
+	if !cv.IsValid() {
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	}
+	if cv.Kind() == reflect.Ptr && !cv.IsNil() && !cv.Type().AssignableTo(rv.Type()) {
+		cv = cv.Elem()
+	}
+	if cv.Type().AssignableTo(rv.Type()) {
+		rv.Set(cv)
+	} else if cv.Type().ConvertibleTo(rv.Type()) {
+		rv.Set(cv.Convert(rv.Type()))
+	} else {
+		return fmt.Errorf("the converter returned %v instead of %v", cv.Type(), rv.Type())
+	}
+	return nil
+}
+
 func readFormField(form map[string][]string, name string, rv reflect.Value) error {
 	value, ok := form[name]
 	if !ok {
@@ -160,14 +678,17 @@ func readFormField(form map[string][]string, name string, rv reflect.Value) erro
 	}
 	rv = indirect(rv)
 	if rv.Kind() != reflect.Slice {
-		return setFormFieldValue(rv, value[0])
+		if err := setFormFieldValue(rv, value[0]); err != nil {
+			return &FormFieldError{Key: name, Value: value[0], Err: err}
+		}
+		return nil
 	}
 
 	n := len(value)
 	slice := reflect.MakeSlice(rv.Type(), n, n)
 	for i := 0; i < n; i++ {
 		if err := setFormFieldValue(slice.Index(i), value[i]); err != nil {
-			return err
+			return &FormFieldError{Key: name, Value: value[i], Err: err}
 		}
 	}
 	rv.Set(slice)
@@ -175,6 +696,10 @@ func readFormField(form map[string][]string, name string, rv reflect.Value) erro
 }
 
 func setFormFieldValue(rv reflect.Value, value string) error {
+	rv = indirect(rv)
+	if ok, err := setKnownTypeValue(rv, value); ok {
+		return err
+	}
 	switch rv.Kind() {
 	case reflect.Bool:
 		if value == "" {
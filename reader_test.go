@@ -2,8 +2,17 @@ package routing
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -108,3 +117,220 @@ func TestTextUnmarshaler(t *testing.T) {
 	assert.Equal(t, "TU_ORIGINAL", a.ATU.UValue)
 	assert.Equal(t, "ORIGINAL", a.NTU)
 }
+
+type formItem struct {
+	Name  string `form:"name"`
+	Count int    `form:"count" default:"1"`
+}
+
+func TestReadFormShapes(t *testing.T) {
+	var a struct {
+		Items   []formItem           `form:"items"`
+		IDs     []int                `form:"ids"`
+		Tags    []string             `form:"tags"`
+		Attrs   map[string]string    `form:"attrs"`
+		Groups  map[string]*formItem `form:"groups"`
+		User    FB                   `form:"user"`
+		Date    time.Time            `form:"date" layout:"2006-01-02"`
+		Empty   time.Time            `form:"empty" layout:"2006-01-02"`
+		Created time.Time            `form:"created"`
+		Status  string               `form:"status" default:"new"`
+		Level   *int                 `form:"level" default:"3"`
+		Missing []formItem           `form:"missing"`
+	}
+	values := map[string][]string{
+		"items[1][name]":      {"b"},
+		"items[0].name":       {"a"},
+		"items[0].count":      {"5"},
+		"ids[0]":              {"1"},
+		"ids[2]":              {"3"},
+		"tags[]":              {"x", "y"},
+		"attrs[color]":        {"red"},
+		"attrs.size":          {"L"},
+		"groups[admin][name]": {"root"},
+		"user[B1]":            {"b1"},
+		"date":                {"2016-01-02"},
+		"empty":               {""},
+		"created":             {"2016-01-02T03:04:05Z"},
+	}
+	err := ReadFormData(values, &a)
+	assert.Nil(t, err)
+	assert.Equal(t, []formItem{{"a", 5}, {"b", 1}}, a.Items)
+	assert.Equal(t, []int{1, 0, 3}, a.IDs)
+	assert.Equal(t, []string{"x", "y"}, a.Tags)
+	assert.Equal(t, map[string]string{"color": "red", "size": "L"}, a.Attrs)
+	if assert.Len(t, a.Groups, 1) {
+		assert.Equal(t, &formItem{"root", 1}, a.Groups["admin"])
+	}
+	assert.Equal(t, "b1", a.User.B1)
+	assert.Equal(t, time.Date(2016, 1, 2, 0, 0, 0, 0, time.UTC), a.Date)
+	assert.True(t, a.Empty.IsZero())
+	assert.Equal(t, time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC), a.Created)
+	assert.Equal(t, "new", a.Status)
+	if assert.NotNil(t, a.Level) {
+		assert.Equal(t, 3, *a.Level)
+	}
+	assert.Nil(t, a.Missing)
+	assert.Len(t, values, 13, "the form values should not be modified")
+
+	err = ReadFormData(map[string][]string{"items[1001].name": {"a"}}, &a)
+	assert.NotNil(t, err)
+	err = ReadFormData(map[string][]string{"date": {"01/02/2016"}}, &a)
+	assert.NotNil(t, err)
+	var b struct {
+		M map[int]string `form:"m"`
+	}
+	err = ReadFormData(map[string][]string{"m.1": {"a"}}, &b)
+	assert.NotNil(t, err)
+}
+
+type formID int64
+
+func TestRegisterFormConverter(t *testing.T) {
+	RegisterFormConverter(reflect.TypeOf(formID(0)), func(value string) (interface{}, error) {
+		if !strings.HasPrefix(value, "id-") {
+			return nil, errors.New("must start with id-")
+		}
+		id, err := strconv.ParseInt(value[3:], 10, 64)
+		return formID(id), err
+	})
+	RegisterFormConverter(reflect.TypeOf(url.URL{}), func(value string) (interface{}, error) {
+		return url.Parse(value)
+	})
+	defer delete(formConverters, reflect.TypeOf(formID(0)))
+	defer delete(formConverters, reflect.TypeOf(url.URL{}))
+
+	var a struct {
+		ID       formID             `form:"id"`
+		Parent   *formID            `form:"parent"`
+		Related  []formID           `form:"related"`
+		Timeout  time.Duration      `form:"timeout"`
+		Retries  []time.Duration    `form:"retries"`
+		IP       net.IP             `form:"ip"`
+		IPs      []net.IP           `form:"ips"`
+		Site     url.URL            `form:"site"`
+		Sites    []url.URL          `form:"sites"`
+		Mirrors  []*url.URL         `form:"mirrors"`
+		Links    map[string]url.URL `form:"links"`
+		Interval time.Duration      `form:"interval"`
+	}
+	values := map[string][]string{
+		"id":       {"id-1"},
+		"parent":   {"id-2"},
+		"related":  {"id-3", "id-4"},
+		"timeout":  {"1m30s"},
+		"retries":  {"1s", "2s"},
+		"ip":       {"127.0.0.1"},
+		"ips":      {"10.0.0.1", "::1"},
+		"site":     {"https://example.com/a"},
+		"sites":    {"https://b.example.com", "https://c.example.com"},
+		"mirrors":  {"https://d.example.com"},
+		"links.x":  {"https://x.example.com"},
+		"interval": {""},
+	}
+	err := ReadFormData(values, &a)
+	assert.Nil(t, err)
+	assert.Equal(t, formID(1), a.ID)
+	if assert.NotNil(t, a.Parent) {
+		assert.Equal(t, formID(2), *a.Parent)
+	}
+	assert.Equal(t, []formID{3, 4}, a.Related)
+	assert.Equal(t, 90*time.Second, a.Timeout)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, a.Retries)
+	assert.Equal(t, "127.0.0.1", a.IP.String())
+	if assert.Len(t, a.IPs, 2) {
+		assert.Equal(t, "::1", a.IPs[1].String())
+	}
+	assert.Equal(t, "example.com", a.Site.Host)
+	// the struct types with converters are populated from single values in slices and maps
+	if assert.Len(t, a.Sites, 2) {
+		assert.Equal(t, "b.example.com", a.Sites[0].Host)
+		assert.Equal(t, "c.example.com", a.Sites[1].Host)
+	}
+	if assert.Len(t, a.Mirrors, 1) {
+		assert.Equal(t, "d.example.com", a.Mirrors[0].Host)
+	}
+	assert.Equal(t, "x.example.com", a.Links["x"].Host)
+	assert.Equal(t, time.Duration(0), a.Interval)
+
+	err = ReadFormData(map[string][]string{"related": {"id-5", "x"}}, &a)
+	if assert.IsType(t, &FormFieldError{}, err) {
+		fe := err.(*FormFieldError)
+		assert.Equal(t, "related", fe.Key)
+		assert.Equal(t, "x", fe.Value)
+		assert.Equal(t, `invalid value "x" for form field "related": must start with id-`, err.Error())
+		assert.Equal(t, http.StatusBadRequest, fe.StatusCode())
+	}
+
+	err = ReadFormData(map[string][]string{"timeout": {"abc"}}, &a)
+	if assert.IsType(t, &FormFieldError{}, err) {
+		assert.Equal(t, "timeout", err.(*FormFieldError).Key)
+	}
+
+	var b struct {
+		Items []formItem `form:"items"`
+	}
+	err = ReadFormData(map[string][]string{"items[0].count": {"abc"}}, &b)
+	if assert.IsType(t, &FormFieldError{}, err) {
+		assert.Equal(t, "items[0].count", err.(*FormFieldError).Key)
+	}
+}
+
+func TestJSONDataReader(t *testing.T) {
+	type item struct {
+		Name  string      `json:"name"`
+		Count int         `json:"count"`
+		Extra interface{} `json:"extra"`
+	}
+	read := func(r *JSONDataReader, body string) (item, error) {
+		var data item
+		req, _ := http.NewRequest("POST", "/test", strings.NewReader(body))
+		err := r.Read(req, &data)
+		return data, err
+	}
+
+	// lenient by default
+	data, err := read(&JSONDataReader{}, `{"name":"a","count":1,"unknown":true} trailing`)
+	assert.Nil(t, err)
+	assert.Equal(t, item{Name: "a", Count: 1}, data)
+
+	_, err = read(&JSONDataReader{DisallowUnknownFields: true}, `{"name":"a","unknown":true}`)
+	assert.Equal(t, &ReadError{Status: http.StatusBadRequest, Message: "unknown field", Field: "unknown", Offset: 27}, err)
+
+	_, err = read(&JSONDataReader{DisallowTrailingData: true}, `{"name":"a"} {}`)
+	assert.Equal(t, &ReadError{Status: http.StatusBadRequest, Message: "unexpected data after the JSON value", Offset: 14}, err)
+	_, err = read(&JSONDataReader{DisallowTrailingData: true}, "{\"name\":\"a\"}\n")
+	assert.Nil(t, err)
+
+	data, err = read(&JSONDataReader{UseNumber: true}, `{"extra":12345678901234567890}`)
+	assert.Nil(t, err)
+	assert.Equal(t, json.Number("12345678901234567890"), data.Extra)
+
+	_, err = read(&JSONDataReader{}, `{"count":"a"}`)
+	if assert.IsType(t, &ReadError{}, err) {
+		assert.Equal(t, "count", err.(*ReadError).Field)
+		assert.Equal(t, int64(12), err.(*ReadError).Offset)
+		assert.Equal(t, `cannot use JSON string as int (field "count", offset 12)`, err.Error())
+		assert.Equal(t, http.StatusBadRequest, err.(HTTPError).StatusCode())
+	}
+
+	_, err = read(&JSONDataReader{}, `{"name" 1}`)
+	if assert.IsType(t, &ReadError{}, err) {
+		assert.Equal(t, int64(9), err.(*ReadError).Offset)
+	}
+	_, err = read(&JSONDataReader{}, `{"name":`)
+	assert.Equal(t, &ReadError{Status: http.StatusBadRequest, Message: "unexpected end of JSON input", Offset: 0}, err)
+	_, err = read(&JSONDataReader{}, "")
+	assert.Equal(t, io.EOF, err)
+
+	// body size limit
+	_, err = read(&JSONDataReader{MaxBodySize: 12}, `{"name":"a"}`)
+	assert.Nil(t, err)
+	_, err = read(&JSONDataReader{MaxBodySize: 11}, `{"name":"a"}`)
+	assert.Equal(t, NewHTTPError(http.StatusRequestEntityTooLarge), err)
+	_, err = read(&JSONDataReader{MaxBodySize: 12, DisallowTrailingData: true}, `{"name":"a"}   `)
+	assert.Equal(t, NewHTTPError(http.StatusRequestEntityTooLarge), err)
+	req, _ := http.NewRequest("POST", "/test", strings.NewReader(`{}`))
+	req.ContentLength = 100
+	assert.Equal(t, NewHTTPError(http.StatusRequestEntityTooLarge), (&JSONDataReader{MaxBodySize: 10}).Read(req, &data))
+}
@@ -0,0 +1,161 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+)
+
+// Response wraps an http.ResponseWriter to keep track of the status, the size and the commit state of the response.
+// Router.ServeHTTP installs a Response as Context.ResponseWriter for every request, and Context.Response returns it
+// even if a handler replaces Context.ResponseWriter with its own wrapper.
+//
+// Response implements http.Flusher, http.Hijacker, http.Pusher and io.ReaderFrom by calling the wrapped writer.
+// If the wrapped writer does not support the corresponding feature, Flush does nothing, while Hijack and Push
+// return an error.
+type Response struct {
+	http.ResponseWriter
+	status  int
+	size    int64
+	written bool
+	discard bool // whether to discard the response body, set for HEAD requests served by GET routes
+	before  []func()
+}
+
+// NewResponse creates a Response wrapping the given http.ResponseWriter.
+func NewResponse(w http.ResponseWriter) *Response {
+	r := &Response{}
+	r.reset(w)
+	return r
+}
+
+// reset prepares the response for writing to the given http.ResponseWriter.
+func (r *Response) reset(w http.ResponseWriter) {
+	r.ResponseWriter = w
+	r.status = http.StatusOK
+	r.size = 0
+	r.written = false
+	r.discard = false
+	r.before = nil
+}
+
+// Status returns the HTTP status code of the response. It is http.StatusOK if no status has been written yet.
+func (r *Response) Status() int {
+	return r.status
+}
+
+// Size returns the number of bytes of the response body written so far.
+// For a HEAD request served by a GET route when Router.AutoHead is enabled, the body is discarded
+// but its size is still counted.
+func (r *Response) Size() int64 {
+	return r.size
+}
+
+// Written returns whether the response headers have been sent, after which the status and the headers
+// can no longer be changed.
+func (r *Response) Written() bool {
+	return r.written
+}
+
+// Before registers a function to be called right before the response headers are sent.
+// The function may still modify the headers. The functions are called in the order they are registered.
+func (r *Response) Before(fn func()) {
+	r.before = append(r.before, fn)
+}
+
+// Unwrap returns the wrapped http.ResponseWriter. It is used by http.ResponseController.
+func (r *Response) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// WriteHeader sends the response headers with the given status code.
+// The call is ignored if the headers have already been sent.
+// Informational statuses (1xx other than 101 Switching Protocols) are forwarded to the wrapped writer
+// without being recorded, so that the final status can still be sent afterwards.
+func (r *Response) WriteHeader(status int) {
+	if r.written {
+		return
+	}
+	if status >= 100 && status <= 199 && status != http.StatusSwitchingProtocols {
+		r.ResponseWriter.WriteHeader(status)
+		return
+	}
+	for _, fn := range r.before {
+		fn()
+	}
+	r.status = status
+	r.written = true
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes the data to the response body, sending the headers with the 200 status first if needed.
+func (r *Response) Write(p []byte) (int, error) {
+	if !r.written {
+		r.WriteHeader(http.StatusOK)
+	}
+	if r.discard {
+		r.size += int64(len(p))
+		return len(p), nil
+	}
+	n, err := r.ResponseWriter.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// ReadFrom copies the data from the reader to the response body, using the io.ReaderFrom implementation
+// of the wrapped writer if available.
+func (r *Response) ReadFrom(src io.Reader) (int64, error) {
+	if !r.written {
+		r.WriteHeader(http.StatusOK)
+	}
+	var (
+		n   int64
+		err error
+	)
+	if r.discard {
+		n, err = io.Copy(io.Discard, src)
+	} else if rf, ok := r.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		n, err = io.Copy(r.ResponseWriter, src)
+	}
+	r.size += n
+	return n, err
+}
+
+// Flush sends the buffered data to the client, sending the headers with the 200 status first if needed.
+func (r *Response) Flush() {
+	if !r.written {
+		r.WriteHeader(http.StatusOK)
+	}
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the caller take over the connection. The response is considered written afterwards.
+func (r *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer does not support hijacking")
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		r.written = true
+	}
+	return conn, rw, err
+}
+
+// Push initiates an HTTP/2 server push. It returns http.ErrNotSupported if the wrapped writer does not support it.
+func (r *Response) Push(target string, opts *http.PushOptions) error {
+	if p, ok := r.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
@@ -0,0 +1,156 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponse(t *testing.T) {
+	rec := httptest.NewRecorder()
+	res := NewResponse(rec)
+	assert.Equal(t, http.StatusOK, res.Status())
+	assert.False(t, res.Written())
+	assert.Equal(t, rec, res.Unwrap())
+
+	var calls []string
+	res.Before(func() {
+		calls = append(calls, "a")
+		res.Header().Set("X-Before", "yes")
+	})
+	res.Before(func() { calls = append(calls, "b") })
+
+	res.WriteHeader(http.StatusCreated)
+	res.WriteHeader(http.StatusBadRequest)
+	n, err := res.Write([]byte("abc"))
+	assert.Equal(t, 3, n)
+	assert.Nil(t, err)
+	n64, err := res.ReadFrom(strings.NewReader("de"))
+	assert.Equal(t, int64(2), n64)
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"a", "b"}, calls)
+	assert.True(t, res.Written())
+	assert.Equal(t, http.StatusCreated, res.Status())
+	assert.Equal(t, int64(5), res.Size())
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "yes", rec.Header().Get("X-Before"))
+	assert.Equal(t, "abcde", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	res = NewResponse(rec)
+	res.Flush()
+	assert.True(t, rec.Flushed)
+	assert.True(t, res.Written())
+
+	_, _, err = res.Hijack()
+	assert.NotNil(t, err)
+	assert.Equal(t, http.ErrNotSupported, res.Push("/app.js", nil))
+}
+
+type statusRecorder struct {
+	*httptest.ResponseRecorder
+	statuses []int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.statuses = append(r.statuses, status)
+	if status >= 200 || status == http.StatusSwitchingProtocols {
+		r.ResponseRecorder.WriteHeader(status)
+	}
+}
+
+func TestResponseInformational(t *testing.T) {
+	rec := &statusRecorder{ResponseRecorder: httptest.NewRecorder()}
+	res := NewResponse(rec)
+	calls := 0
+	res.Before(func() { calls++ })
+
+	res.WriteHeader(http.StatusContinue)
+	res.WriteHeader(http.StatusEarlyHints)
+	assert.False(t, res.Written())
+	assert.Equal(t, http.StatusOK, res.Status())
+	assert.Equal(t, 0, calls)
+
+	res.WriteHeader(http.StatusAccepted)
+	res.Write([]byte("ok"))
+	assert.True(t, res.Written())
+	assert.Equal(t, http.StatusAccepted, res.Status())
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, []int{http.StatusContinue, http.StatusEarlyHints, http.StatusAccepted}, rec.statuses)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	rec = &statusRecorder{ResponseRecorder: httptest.NewRecorder()}
+	res = NewResponse(rec)
+	res.WriteHeader(http.StatusSwitchingProtocols)
+	assert.True(t, res.Written())
+	assert.Equal(t, http.StatusSwitchingProtocols, res.Status())
+}
+
+func TestRouterResponse(t *testing.T) {
+	r := New()
+	r.Get("/partial", func(c *Context) error {
+		c.Write("partial")
+		return errors.New("failed")
+	})
+	r.Get("/flush", func(c *Context) error {
+		flusher, ok := c.ResponseWriter.(http.Flusher)
+		if assert.True(t, ok) {
+			c.Write("data")
+			flusher.Flush()
+		}
+		assert.Equal(t, int64(4), c.Response().Size())
+		return nil
+	})
+	r.Get("/error", func(c *Context) error {
+		return NewHTTPError(http.StatusConflict)
+	})
+
+	// the error is not written after the response is started
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/partial", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "partial", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/flush", nil)
+	r.ServeHTTP(res, req)
+	assert.True(t, res.Flushed)
+	assert.Equal(t, "data", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/error", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusConflict, res.Code)
+
+	// the response wrapper is shared with mounted routers
+	sub := New()
+	var inner *Response
+	sub.Get("/x", func(c *Context) error {
+		inner = c.Response()
+		return nil
+	})
+	var outer *Response
+	r.Use(func(c *Context) error {
+		outer = c.Response()
+		return nil
+	})
+	r.Mount("/sub", sub)
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/sub/x", nil)
+	r.ServeHTTP(res, req)
+	if assert.NotNil(t, inner) {
+		assert.Equal(t, outer, inner)
+	}
+
+	assert.Nil(t, NewContext(nil, nil).Response())
+}
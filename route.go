@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"sync/atomic"
 )
 
 // Route represents a URL path pattern that can be used to match requested URLs.
@@ -17,13 +18,30 @@ type Route struct {
 	name, template string
 	tags           []interface{}
 	routes         []*Route
+
+	handlers         []Handler    // the handlers of the route, including those inherited from the group
+	startupHandlers  []Handler    // the handlers executed before the route handlers
+	shutdownHandlers []Handler    // the handlers executed after the route handlers, even if they fail
+	useIndex         int          // the position in handlers where handlers registered via Use are inserted
+	uses             []Handler    // the handlers registered via Use
+	chain            atomic.Value // the *routeChain executed when the route is matched, replaced as a whole on changes
+}
+
+// routeChain holds the handlers executed when a route is matched, including the lifecycle handlers
+// of the route group and of its parent groups.
+type routeChain struct {
+	handlers  []Handler // the startup and anterior handlers of the groups, the startup handlers of the route and its handlers
+	posterior []Handler // the posterior handlers of the groups
+	shutdown  []Handler // the shutdown handlers of the route, followed by those of the groups
 }
 
 // Name sets the name of the route.
 // This method will update the registration of the route in the router as well.
 func (r *Route) Name(name string) *Route {
 	r.name = name
-	r.group.router.namedRoutes[name] = r
+	r.group.router.update(func(t *routeTable) {
+		t.namedRoutes[name] = r
+	})
 	return r
 }
 
@@ -43,11 +61,124 @@ func (r *Route) Tag(value interface{}) *Route {
 	return r
 }
 
+// Use inserts the specified handlers into the handler chain of the route.
+// The handlers will be executed after the handlers inherited from the route group and before
+// the handlers given when the route was added, in the order they are registered.
+// If the route is a composite one (a path with multiple methods), the handlers are added to each of them.
+// Use is not safe to call while the router is serving requests. Call Router.Replace instead.
+func (r *Route) Use(handlers ...Handler) *Route {
+	if len(r.routes) > 0 {
+		for _, route := range r.routes {
+			route.Use(handlers...)
+		}
+		return r
+	}
+	r.handlers = combineHandlers(r.handlers[:r.useIndex], handlers, r.handlers[r.useIndex:])
+	r.useIndex += len(handlers)
+	r.uses = combineHandlers(r.uses, handlers)
+	r.updateChain()
+	return r
+}
+
+// AppendStartupHandler registers handlers to be executed before all other handlers of the route.
+// If the route is a composite one (a path with multiple methods), the handlers are added to each of them.
+func (r *Route) AppendStartupHandler(handlers ...Handler) *Route {
+	if len(r.routes) > 0 {
+		for _, route := range r.routes {
+			route.AppendStartupHandler(handlers...)
+		}
+		return r
+	}
+	r.startupHandlers = combineHandlers(r.startupHandlers, handlers)
+	r.updateChain()
+	return r
+}
+
+// AppendShutdownHandler registers handlers to be executed after all other handlers of the route.
+// The shutdown handlers are executed even if the other handlers return an error, call Context.Abort or panic.
+// If the route is a composite one (a path with multiple methods), the handlers are added to each of them.
+func (r *Route) AppendShutdownHandler(handlers ...Handler) *Route {
+	if len(r.routes) > 0 {
+		for _, route := range r.routes {
+			route.AppendShutdownHandler(handlers...)
+		}
+		return r
+	}
+	r.shutdownHandlers = combineHandlers(r.shutdownHandlers, handlers)
+	r.updateChain()
+	return r
+}
+
+// Handlers returns the handlers of the route, including those inherited from the route group
+// and those registered via Use.
+func (r *Route) Handlers() []Handler {
+	return r.handlers
+}
+
+// HandlerChain returns all handlers executed when the route is matched, in the order of their execution.
+// Besides the handlers of the route, it includes the lifecycle handlers of the route, of its group
+// and of the parent groups.
+// The posterior and shutdown handlers at the end of the chain are executed even if the preceding handlers fail.
+func (r *Route) HandlerChain() []Handler {
+	rc := r.getChain()
+	return combineHandlers(rc.handlers, rc.posterior, rc.shutdown)
+}
+
+// setHandlers sets the handlers of the route when it is added to the router.
+func (r *Route) setHandlers(handlers []Handler) {
+	r.handlers = handlers
+	r.useIndex = len(r.group.handlers)
+	r.updateChain()
+}
+
+// replacement creates a route with the same method, path, name and tags to replace the route via Router.Replace.
+// The new route uses the given handlers, preceded by the handlers of the route group and those registered via Use,
+// and it keeps the startup and shutdown handlers of the route.
+func (r *Route) replacement(handlers []Handler) *Route {
+	nr := r.group.newRoute(r.method, r.path)
+	nr.name, nr.tags = r.name, r.tags
+	nr.startupHandlers, nr.shutdownHandlers = r.startupHandlers, r.shutdownHandlers
+	nr.setHandlers(combineHandlers(r.group.handlers, r.uses, handlers))
+	nr.useIndex += len(r.uses)
+	nr.uses = r.uses
+	return nr
+}
+
+// updateChain rebuilds the handler chain that is used when the route is matched.
+func (r *Route) updateChain() {
+	r.chain.Store(r.resolveChain())
+}
+
+// getChain returns the handler chain that is used when the route is matched.
+func (r *Route) getChain() *routeChain {
+	if rc, ok := r.chain.Load().(*routeChain); ok {
+		return rc
+	}
+	// a composite route, whose handlers are those of its routes
+	return r.resolveChain()
+}
+
+// resolveChain combines the handlers of the route with the lifecycle handlers of its group and of the parent groups.
+func (r *Route) resolveChain() *routeChain {
+	l := r.group.getLifecycle()
+	return &routeChain{
+		handlers:  combineHandlers(l.startup, l.anterior, r.startupHandlers, r.handlers),
+		posterior: l.posterior,
+		shutdown:  combineHandlers(r.shutdownHandlers, l.shutdown),
+	}
+}
+
 // Method returns the HTTP method that this route is associated with.
 func (r *Route) Method() string {
 	return r.method
 }
 
+// Host returns the host pattern that this route should match.
+// An empty string is returned if the route matches any host.
+func (r *Route) Host() string {
+	return r.group.host
+}
+
 // Path returns the request path that this route should match.
 func (r *Route) Path() string {
 	return r.group.prefix + r.path
@@ -113,6 +244,7 @@ func (r *Route) To(methods string, handlers ...Handler) *Route {
 // The parameters should be given in the sequence of name1, value1, name2, value2, and so on.
 // If a parameter in the route is not provided a value, the parameter token will remain in the resulting URL.
 // The method will perform URL encoding for all given parameter values.
+// If the route was registered via Router.Host, the URL is scheme-relative and starts with "//" followed by the host.
 func (r *Route) URL(pairs ...interface{}) (s string) {
 	s = r.template
 	for i := 0; i < len(pairs); i++ {
@@ -128,5 +260,5 @@ func (r *Route) URL(pairs ...interface{}) (s string) {
 
 // String returns the string representation of the route.
 func (r *Route) String() string {
-	return r.method + " " + r.group.prefix + r.path
+	return r.method + " " + r.group.hostPrefix() + r.group.prefix + r.path
 }
@@ -0,0 +1,45 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteUse(t *testing.T) {
+	write := func(s string) Handler {
+		return func(c *Context) error {
+			fmt.Fprint(c.ResponseWriter, s)
+			return nil
+		}
+	}
+	r := New()
+	api := r.Group("/api", write("group,"))
+	route := api.Get("/users", write("users"))
+	route.Use(write("auth,")).Use(write("log,"))
+	route.AppendStartupHandler(write("start,")).AppendShutdownHandler(write(",end"))
+	composite := api.To("PUT,POST", "/items", write("items")).Use(write("auth,"))
+	assert.Equal(t, 4, len(route.Handlers()))
+
+	tests := []struct {
+		method, path, body string
+	}{
+		{"GET", "/api/users", "start,group,auth,log,users,end"},
+		{"PUT", "/api/items", "group,auth,items"},
+		{"POST", "/api/items", "group,auth,items"},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest(test.method, test.path, nil)
+		r.ServeHTTP(res, req)
+		assert.Equal(t, test.body, res.Body.String(), test.method+" "+test.path)
+	}
+	assert.Equal(t, 0, len(composite.Handlers()))
+}
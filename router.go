@@ -6,11 +6,13 @@
 package routing
 
 import (
+	"errors"
 	"net/http"
 	"net/url"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 type (
@@ -22,13 +24,18 @@ type (
 		RouteGroup
 		IgnoreTrailingSlash bool // whether to ignore trailing slashes in the end of the request URL
 		UseEscapedPath      bool // whether to use encoded URL instead of decoded URL to match routes
+		Strict              bool // whether to panic when adding a route that duplicates or is shadowed by an existing route
+		AutoHead            bool // whether to serve HEAD requests with the matching GET routes, discarding the response body
+		AutoOptions         bool // whether to answer OPTIONS requests with the allowed methods when no OPTIONS route matches
 		pool                sync.Pool
-		routes              []*Route
-		namedRoutes         map[string]*Route
-		stores              map[string]routeStore
-		maxParams           int
+		mu                  sync.Mutex   // guards pending, batches and the updates of errorStatuses
+		table               atomic.Value // the published *routeTable used to serve requests
+		pending             *routeTable  // the unpublished copy of the table being modified, nil if none
+		batches             int          // the number of running batches, during which pending is not published
+		dirty               int32        // whether pending is not nil, accessed atomically
 		notFound            []Handler
 		notFoundHandlers    []Handler
+		errorStatuses       atomic.Value // the []errorStatus registered via MapError, replaced as a whole on updates
 	}
 
 	// routeStore stores route paths and the corresponding handlers.
@@ -54,16 +61,14 @@ var Methods = []string{
 
 // New creates a new Router object.
 func New() *Router {
-	r := &Router{
-		namedRoutes: make(map[string]*Route),
-		stores:      make(map[string]routeStore),
-	}
+	r := &Router{}
+	r.table.Store(newRouteTable())
 	r.RouteGroup = *newRouteGroup("", r, make([]Handler, 0),
 		make([]Handler, 0), make([]Handler, 0), make([]Handler, 0), make([]Handler, 0))
 	r.NotFound(MethodNotAllowedHandler, NotFoundHandler)
 	r.pool.New = func() interface{} {
 		return &Context{
-			pvalues: make([]string, r.maxParams),
+			pvalues: make([]string, r.loadTable().maxParams),
 			router:  r,
 		}
 	}
@@ -73,18 +78,24 @@ func New() *Router {
 // ServeHTTP handles the HTTP request.
 // It is required by http.Handler
 func (r *Router) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	t := r.loadTable()
 	c := r.pool.Get().(*Context)
+	if len(c.pvalues) < t.maxParams {
+		// routes with more parameters were added after the context was created
+		c.pvalues = make([]string, t.maxParams)
+	}
 	c.init(res, req)
+	c.route, c.handlers, c.pnames = r.find(t, req.Method, normalizeRequestHost(req.Host), r.requestPath(req), c.pvalues)
+	if c.route != nil && c.route.method != req.Method && c.response != nil {
+		// a HEAD request served by a GET route because of AutoHead
+		c.response.discard = true
+	}
 	if r.UseEscapedPath {
-		c.handlers, c.pnames = r.find(req.Method, r.normalizeRequestPath(req.URL.EscapedPath()), c.pvalues)
 		for i, v := range c.pvalues {
 			c.pvalues[i], _ = url.QueryUnescape(v)
 		}
-	} else {
-		c.handlers, c.pnames = r.find(req.Method, r.normalizeRequestPath(req.URL.Path), c.pvalues)
 	}
-	c.handlers = combineHandlers(r.RouteGroup.startupHandlers, r.RouteGroup.anteriorHandlers, c.handlers, r.RouteGroup.posteriorHandlers, r.RouteGroup.shutdownHandlers)
-	if err := c.Next(); err != nil {
+	if err := r.serve(c); err != nil {
 		r.handleError(c, err)
 	}
 	c.Context = nil
@@ -92,15 +103,131 @@ func (r *Router) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	return
 }
 
+// serve executes the handlers of the request in three phases and returns the combined error of all phases.
+// The lifecycle handlers are those of the group of the matching route and of its parent groups, which are resolved
+// in the handler chain of the route when it is registered or when lifecycle handlers are appended,
+// or those of the router if no route matches.
+//
+// The startup, anterior and route handlers are executed first. As usual, a handler may skip the rest of them
+// by returning an error or calling Context.Abort. The posterior handlers are then executed regardless of the outcome
+// of the first phase, which they can get via Context.HandlerError. Finally, the shutdown handlers of the route
+// and of the router are executed, even if a handler panics. An error returned by a posterior or shutdown handler
+// does not prevent the execution of the rest of them.
+func (r *Router) serve(c *Context) (err error) {
+	if c.route != nil {
+		// the handlers of the chain are those found for the request
+		rc := c.route.getChain()
+		defer func() {
+			err = c.runAll(rc.shutdown, err)
+		}()
+		err = c.Next()
+		return c.runAll(rc.posterior, err)
+	}
+
+	l := r.getLifecycle()
+	defer func() {
+		err = c.runAll(l.shutdown, err)
+	}()
+	if len(l.startup) > 0 || len(l.anterior) > 0 {
+		c.handlers = combineHandlers(l.startup, l.anterior, c.handlers)
+	}
+	err = c.Next()
+	return c.runAll(l.posterior, err)
+}
+
+// Host creates a RouteGroup whose routes only match requests with the given host.
+// The host pattern may contain parameter tokens, such as "<tenant>.example.com". A token without a pattern
+// matches a single host label (any characters except dots). Host parameters can be accessed via Context.Param
+// like path parameters, and are included in the URLs created by Route.URL and Context.URL.
+// The port part of the request host is ignored, and host names are matched case-insensitively.
+// Routes that are not registered under any host will match requests for every host.
+// If no handler is provided, the new group will inherit the handlers registered with the router.
+func (r *Router) Host(host string, handlers ...Handler) *RouteGroup {
+	rg := r.RouteGroup.Group("", handlers...)
+	rg.host = host
+	return rg
+}
+
 // Route returns the named route.
+// Named routes of the routers mounted via RouteGroup.Mount are also looked up, in which case
+// a copy of the route generating URLs with the mount prefix is returned.
 // Nil is returned if the named route cannot be found.
 func (r *Router) Route(name string) *Route {
-	return r.namedRoutes[name]
+	t := r.loadTable()
+	if route := t.namedRoutes[name]; route != nil {
+		return route
+	}
+	for _, m := range t.mounts {
+		if route := m.router.Route(name); route != nil {
+			mounted := *route
+			mounted.template = m.template + route.template
+			return &mounted
+		}
+	}
+	return nil
 }
 
 // Routes returns all routes managed by the router.
 func (r *Router) Routes() []*Route {
-	return r.routes
+	return r.loadTable().routes
+}
+
+// Remove removes the route from the router. If the route is a composite one (a path with multiple methods),
+// all its routes are removed. It returns false if the route is not managed by the router.
+// Remove can be called while the router is serving requests. Requests that are being served
+// are not affected, and new requests will not match the removed route.
+func (r *Router) Remove(route *Route) bool {
+	removed := false
+	r.update(func(t *routeTable) {
+		if len(route.routes) == 0 {
+			removed = t.remove(route)
+			return
+		}
+		for _, rt := range route.routes {
+			if t.remove(rt) {
+				removed = true
+			}
+		}
+		t.unname(route)
+	})
+	return removed
+}
+
+// Replace replaces the route with a new route of the same method, path, name and tags, using the given handlers.
+// The handlers registered with the route group of the route and those registered via Route.Use are prepended
+// to the given handlers. The startup and shutdown handlers of the route are kept.
+// If the route is a composite one (a path with multiple methods), all its routes are replaced.
+// The new route is returned, or nil if the route is not managed by the router.
+// Replace can be called while the router is serving requests. Requests that are being served
+// continue using the old route, and new requests will use the new one.
+func (r *Router) Replace(route *Route, handlers ...Handler) *Route {
+	var newRoute *Route
+	replaced := false
+	r.update(func(t *routeTable) {
+		if len(route.routes) == 0 {
+			newRoute = route.replacement(handlers)
+			replaced = t.replace(route, newRoute)
+			return
+		}
+		newRoute = route.group.newRoute(route.method, route.path)
+		newRoute.name, newRoute.tags = route.name, route.tags
+		for _, rt := range route.routes {
+			nr := rt.replacement(handlers)
+			if t.replace(rt, nr) {
+				newRoute.routes = append(newRoute.routes, nr)
+				replaced = true
+			}
+		}
+		for name, named := range t.namedRoutes {
+			if named == route {
+				t.namedRoutes[name] = newRoute
+			}
+		}
+	})
+	if !replaced {
+		return nil
+	}
+	return newRoute
 }
 
 // Startup prepends the specified handlers to the router and shares them with all routes.
@@ -133,6 +260,7 @@ func (r *Router) Use(handlers ...Handler) {
 
 // NotFound specifies the handlers that should be invoked when the router cannot find any route matching a request.
 // Note that the handlers registered via Use will be invoked first in this case.
+// Route groups may override the not-found handlers for the paths under their prefixes via RouteGroup.NotFound.
 func (r *Router) NotFound(handlers ...Handler) *Router {
 	r.notFound = handlers
 	r.notFoundHandlers = combineHandlers(r.handlers, r.notFound)
@@ -140,9 +268,15 @@ func (r *Router) NotFound(handlers ...Handler) *Router {
 }
 
 // Find determines the handlers and parameters to use for a specified method and path.
-func (r *Router) Find(method, path string) (handlers []Handler, params map[string]string) {
-	pvalues := make([]string, r.maxParams)
-	handlers, pnames := r.find(method, path, pvalues)
+// An optional host may be given to match routes registered via Host.
+func (r *Router) Find(method, path string, host ...string) (handlers []Handler, params map[string]string) {
+	t := r.loadTable()
+	pvalues := make([]string, t.maxParams)
+	h := ""
+	if len(host) > 0 {
+		h = normalizeRequestHost(host[0])
+	}
+	_, handlers, pnames := r.find(t, method, h, path, pvalues)
 	params = make(map[string]string, len(pnames))
 	for i, n := range pnames {
 		params[n] = pvalues[i]
@@ -150,56 +284,168 @@ func (r *Router) Find(method, path string) (handlers []Handler, params map[strin
 	return handlers, params
 }
 
+// AllowedMethods returns the sorted list of HTTP methods allowed for the given path, or nil if no route matches it.
+// The list includes OPTIONS, as well as HEAD if AutoHead is enabled and GET is allowed.
+// The path is normalized according to IgnoreTrailingSlash. It should be escaped if UseEscapedPath is enabled.
+// If a host is given, the routes registered via Host that match it are also considered.
+// AllowedMethods can be used by custom handlers to respond to requests with unsupported methods.
+func (r *Router) AllowedMethods(path string, host ...string) []string {
+	h := ""
+	if len(host) > 0 {
+		h = normalizeRequestHost(host[0])
+	}
+	return r.allowedMethods(r.loadTable(), h, r.normalizeRequestPath(path))
+}
+
+// serveMounted dispatches the request of the given context to the router as a mounted router, using the given path.
+func (r *Router) serveMounted(c *Context, path string) error {
+	req := *c.Request
+	u := *req.URL
+	u.Path, u.RawPath = path, ""
+	req.URL = &u
+	r.ServeHTTP(c.ResponseWriter, &req)
+	return nil
+}
+
 // handleError is the error handler for handling any unhandled errors.
+// A Problem, or an error wrapping one, is written via Context.WriteProblem. Other errors are written as plain text
+// with the status code determined by ErrorStatus.
+// The error handler of the route group matching the request is used if any, as described in RouteGroup.OnError.
 func (r *Router) handleError(c *Context, err error) {
-	if httpError, ok := err.(HTTPError); ok {
-		http.Error(c.ResponseWriter, httpError.Error(), httpError.StatusCode())
+	if res := c.Response(); res != nil && res.Written() {
+		// the response has been started and can no longer report the error
+		return
+	}
+	t := r.loadTable()
+	if len(t.scopes) > 0 {
+		// the parameter values of the context are kept for the error handler
+		pvalues := make([]string, t.maxParams)
+		if rg := t.findGroup(normalizeRequestHost(c.Request.Host), r.requestPath(c.Request), pvalues, hasErrorHandler); rg != nil {
+			rg.errorHandler(c, err)
+			return
+		}
+	}
+	var problem *Problem
+	if errors.As(err, &problem) {
+		c.WriteProblem(problem)
 	} else {
-		http.Error(c.ResponseWriter, err.Error(), http.StatusInternalServerError)
+		http.Error(c.ResponseWriter, err.Error(), r.ErrorStatus(err))
 	}
 }
 
 func (r *Router) addRoute(route *Route, handlers []Handler) {
-	path := route.group.prefix + route.path
-
-	r.routes = append(r.routes, route)
+	r.update(func(t *routeTable) {
+		// the handlers are resolved with the lock held so that the lifecycle handlers appended meanwhile are included
+		route.setHandlers(handlers)
+		if r.Strict {
+			if err := findConflict(route, t.routes); err != nil {
+				panic(err)
+			}
+		}
+		t.add(route)
+	})
+}
 
-	store := r.stores[route.method]
-	if store == nil {
-		store = newStore()
-		r.stores[route.method] = store
+// update calls the given function to modify a copy of the route table. The modified copy is published
+// to serve requests the next time the table is loaded.
+func (r *Router) update(fn func(t *routeTable)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending == nil {
+		r.pending = r.table.Load().(*routeTable).clone()
+		atomic.StoreInt32(&r.dirty, 1)
 	}
+	fn(r.pending)
+}
+
+// batch calls the given function, which may update the route table several times, and publishes the changes
+// only after the function returns, so that requests never observe a partially registered composite route or mount.
+// Batches may be nested.
+func (r *Router) batch(fn func()) {
+	r.mu.Lock()
+	r.batches++
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.batches--
+		r.mu.Unlock()
+	}()
+	fn()
+}
 
-	// an asterisk at the end matches any number of characters
-	if strings.HasSuffix(path, "*") {
-		path = path[:len(path)-1] + "<:.*>"
+// loadTable publishes the pending changes to the route table, if any, and returns the published table.
+// The pending changes are not published while a batch is running.
+// The returned table must not be modified.
+func (r *Router) loadTable() *routeTable {
+	if atomic.LoadInt32(&r.dirty) != 0 {
+		r.mu.Lock()
+		if r.pending != nil && r.batches == 0 {
+			r.table.Store(r.pending)
+			r.pending = nil
+			atomic.StoreInt32(&r.dirty, 0)
+		}
+		r.mu.Unlock()
 	}
+	return r.table.Load().(*routeTable)
+}
 
-	if n := store.Add(path, handlers); n > r.maxParams {
-		r.maxParams = n
+// find determines the route, handlers and parameters to use for a specified method, host and path using the given route table.
+// The route is nil if no route matches the request.
+func (r *Router) find(t *routeTable, method, host, path string, pvalues []string) (*Route, []Handler, []string) {
+	if route, pnames := t.find(method, host, path, pvalues); route != nil {
+		return route, route.getChain().handlers, pnames
+	}
+	if method == "HEAD" && r.AutoHead {
+		if route, pnames := t.find("GET", host, path, pvalues); route != nil {
+			return route, route.getChain().handlers, pnames
+		}
+	}
+	if method == "OPTIONS" && r.AutoOptions {
+		if methods := t.findAllowedMethods(host, path); len(methods) > 0 {
+			return nil, combineHandlers(r.handlers, []Handler{allowMethods}), nil
+		}
+	}
+	if rg := t.findGroup(host, path, pvalues, hasNotFound); rg != nil {
+		return nil, rg.notFound, nil
 	}
+	return nil, r.notFoundHandlers, nil
 }
 
-func (r *Router) find(method, path string, pvalues []string) (handlers []Handler, pnames []string) {
-	var hh interface{}
-	if store := r.stores[method]; store != nil {
-		hh, pnames = store.Get(path, pvalues)
+// hasNotFound returns whether the route group has its own not-found handlers.
+func hasNotFound(rg *RouteGroup) bool {
+	return rg.notFound != nil
+}
+
+// hasErrorHandler returns whether the route group has its own error handler.
+func hasErrorHandler(rg *RouteGroup) bool {
+	return rg.errorHandler != nil
+}
+
+// allowedMethods returns the sorted list of HTTP methods allowed for the given host and path,
+// or nil if no route matches the path.
+func (r *Router) allowedMethods(t *routeTable, host, path string) []string {
+	methods := t.findAllowedMethods(host, path)
+	if len(methods) == 0 {
+		return nil
 	}
-	if hh != nil {
-		return hh.([]Handler), pnames
+	methods["OPTIONS"] = true
+	if r.AutoHead && methods["GET"] {
+		methods["HEAD"] = true
 	}
-	return r.notFoundHandlers, pnames
+	ms := make([]string, 0, len(methods))
+	for method := range methods {
+		ms = append(ms, method)
+	}
+	sort.Strings(ms)
+	return ms
 }
 
-func (r *Router) findAllowedMethods(path string) map[string]bool {
-	methods := make(map[string]bool)
-	pvalues := make([]string, r.maxParams)
-	for m, store := range r.stores {
-		if handlers, _ := store.Get(path, pvalues); handlers != nil {
-			methods[m] = true
-		}
+// requestPath returns the path of the request used to match routes.
+func (r *Router) requestPath(req *http.Request) string {
+	if r.UseEscapedPath {
+		return r.normalizeRequestPath(req.URL.EscapedPath())
 	}
-	return methods
+	return r.normalizeRequestPath(req.URL.Path)
 }
 
 func (r *Router) normalizeRequestPath(path string) string {
@@ -214,6 +460,44 @@ func (r *Router) normalizeRequestPath(path string) string {
 	return path
 }
 
+// normalizeRequestHost removes the port from the given request host and converts it into lower case.
+func normalizeRequestHost(host string) string {
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && !strings.Contains(host[i:], "]") {
+		host = host[:i]
+	}
+	return strings.ToLower(host)
+}
+
+// buildHostPattern converts the parameter tokens without a pattern in a host pattern
+// into tokens that match a single host label. The literal parts are converted to lower case
+// to match the normalized request hosts.
+func buildHostPattern(host string) string {
+	pattern, start := "", -1
+	for i := 0; i < len(host); i++ {
+		if host[i] == '<' && start < 0 {
+			start = i
+		} else if host[i] == '>' && start >= 0 {
+			token := host[start+1 : i]
+			if !strings.Contains(token, ":") {
+				token += ":[^.]+"
+			}
+			pattern += "<" + token + ">"
+			start = -1
+		} else if start < 0 {
+			pattern += strings.ToLower(host[i : i+1])
+		}
+	}
+	return pattern
+}
+
+// combineNames merges the host parameter names with the path parameter names into a new list.
+func combineNames(hnames, pnames []string) []string {
+	names := make([]string, len(hnames)+len(pnames))
+	copy(names, hnames)
+	copy(names[len(hnames):], pnames)
+	return names
+}
+
 // NotFoundHandler returns a 404 HTTP error indicating a request has no matching route.
 func NotFoundHandler(c *Context) error {
 	return NewHTTPError(http.StatusNotFound)
@@ -223,18 +507,11 @@ func NotFoundHandler(c *Context) error {
 // In this case, the handler will respond with an Allow HTTP header listing the allowed HTTP methods.
 // Otherwise, the handler will do nothing and let the next handler (usually a NotFoundHandler) to handle the problem.
 func MethodNotAllowedHandler(c *Context) error {
-	methods := c.Router().findAllowedMethods(c.Request.URL.Path)
-	if len(methods) == 0 {
+	r := c.Router()
+	ms := r.allowedMethods(r.loadTable(), normalizeRequestHost(c.Request.Host), r.requestPath(c.Request))
+	if len(ms) == 0 {
 		return nil
 	}
-	methods["OPTIONS"] = true
-	ms := make([]string, len(methods))
-	i := 0
-	for method := range methods {
-		ms[i] = method
-		i++
-	}
-	sort.Strings(ms)
 	c.ResponseWriter.Header().Set("Allow", strings.Join(ms, ", "))
 	if c.Request.Method != "OPTIONS" {
 		c.ResponseWriter.WriteHeader(http.StatusMethodNotAllowed)
@@ -243,6 +520,15 @@ func MethodNotAllowedHandler(c *Context) error {
 	return nil
 }
 
+// allowMethods answers an OPTIONS request with an Allow HTTP header listing the allowed HTTP methods.
+// It is used when Router.AutoOptions is enabled and no OPTIONS route matches the request.
+func allowMethods(c *Context) error {
+	r := c.Router()
+	ms := r.allowedMethods(r.loadTable(), normalizeRequestHost(c.Request.Host), r.requestPath(c.Request))
+	c.ResponseWriter.Header().Set("Allow", strings.Join(ms, ", "))
+	return nil
+}
+
 // HTTPHandlerFunc adapts a http.HandlerFunc into a routing.Handler.
 func HTTPHandlerFunc(h http.HandlerFunc) Handler {
 	return func(c *Context) error {
@@ -14,6 +14,7 @@ import (
 // store is a radix tree that supports storing data with parametric keys and retrieving them back with concrete keys.
 // When retrieving a data item with a concrete key, the matching parameter names and values will be returned as well.
 // A parametric key is a string containing tokens in the format of "<name>", "<name:pattern>", or "<:pattern>".
+// The pattern may also be the name of a typed parameter registered via RegisterParamType, such as "<id:int>".
 // Each token represents a single parameter.
 type store struct {
 	root  *node // the root node of the radix tree
@@ -66,9 +67,10 @@ type node struct {
 	children  []*node // child static nodes, indexed by the first byte of each child key
 	pchildren []*node // child param nodes
 
-	regex  *regexp.Regexp // regular expression for a param node containing regular expression key
-	pindex int            // the parameter index, meaningful only for param node
-	pnames []string       // the parameter names collected from the root till this node
+	regex   *regexp.Regexp // regular expression for a param node containing regular expression key
+	matcher ParamMatcher   // typed parameter matcher for a param node containing a typed key
+	pindex  int            // the parameter index, meaningful only for param node
+	pnames  []string       // the parameter names collected from the root till this node
 }
 
 // add adds a new data item to the tree rooted at the current node.
@@ -197,7 +199,10 @@ func (n *node) addChild(key string, data interface{}, order int) int {
 			break
 		}
 	}
-	if pattern != "" {
+	if matcher, ok := paramMatchers[pattern]; ok {
+		// the param token refers to a typed parameter
+		child.matcher = matcher
+	} else if pattern != "" {
 		// the param token contains a regular expression
 		child.regex = regexp.MustCompile("^" + pattern)
 	}
@@ -248,6 +253,14 @@ repeat:
 		} else {
 			return
 		}
+	} else if n.matcher != nil {
+		// param node with typed parameter matcher
+		if l := n.matcher(key); l > 0 {
+			pvalues[n.pindex] = key[0:l]
+			key = key[l:]
+		} else {
+			return
+		}
 	} else {
 		// param node matching non-"/" characters
 		i, kl := 0, len(key)
@@ -162,3 +162,51 @@ func TestStoreGet(t *testing.T) {
 		assert.Equal(t, test.params, params, "store.Get("+test.key+").params =")
 	}
 }
+
+func TestStoreGetTyped(t *testing.T) {
+	h := newStore()
+	maxParams := 0
+	for _, pair := range []struct {
+		key, value string
+	}{
+		{"/users/<id:int>", "1"},
+		{"/users/<id:int>/posts/<d:date>", "2"},
+		{"/users/<name:slug>", "3"},
+		{"/files/<uuid:uuid>", "4"},
+		{"/files/<uuid:uuid>.json", "5"},
+	} {
+		if n := h.Add(pair.key, pair.value); n > maxParams {
+			maxParams = n
+		}
+	}
+
+	tests := []struct {
+		key    string
+		value  interface{}
+		params string
+	}{
+		{"/users/123", "1", "id:123,"},
+		{"/users/-12", "1", "id:-12,"},
+		{"/users/123/posts/2016-02-29", "2", "id:123,d:2016-02-29,"},
+		{"/users/123/posts/2017-02-29", nil, ""},
+		{"/users/john-doe", "3", "name:john-doe,"},
+		{"/users/12abc", "3", "name:12abc,"},
+		{"/users/John", nil, ""},
+		{"/users/john--doe", nil, ""},
+		{"/files/0b7e5e58-6d5c-4b0f-9d24-5f2b8a3f1c2d", "4", "uuid:0b7e5e58-6d5c-4b0f-9d24-5f2b8a3f1c2d,"},
+		{"/files/0b7e5e58-6d5c-4b0f-9d24-5f2b8a3f1c2d.json", "5", "uuid:0b7e5e58-6d5c-4b0f-9d24-5f2b8a3f1c2d,"},
+		{"/files/0b7e5e58-6d5c-4b0f-9d24", nil, ""},
+	}
+	pvalues := make([]string, maxParams)
+	for _, test := range tests {
+		data, pnames := h.Get(test.key, pvalues)
+		assert.Equal(t, test.value, data, "store.Get("+test.key+") =")
+		params := ""
+		if data != nil {
+			for i, name := range pnames {
+				params += fmt.Sprintf("%v:%v,", name, pvalues[i])
+			}
+		}
+		assert.Equal(t, test.params, params, "store.Get("+test.key+").params =")
+	}
+}
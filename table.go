@@ -0,0 +1,364 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+type (
+	// routeTable holds the routes managed by a router and the stores used to match them.
+	// Once a table is published to the router, it is never modified. Changes are made to a copy
+	// which replaces the published table atomically.
+	routeTable struct {
+		routes      []*Route
+		namedRoutes map[string]*Route
+		stores      map[string]routeStore
+		patterns    *patternIndex
+		hosts       []*hostRoutes
+		mounts      []*mount
+		scopes      []*groupScope // sorted by the length of the group prefixes in descending order
+		maxParams   int
+	}
+
+	// groupScope matches the requests falling under the host and the path prefix of a route group
+	// that has its own not-found or error handlers.
+	groupScope struct {
+		group  *RouteGroup
+		host   routeStore // the store matching the host pattern, nil if the group matches any host
+		prefix routeStore // the store matching the paths under the path prefix
+		params int        // the number of parameters in the host pattern and the path prefix
+	}
+
+	// hostRoutes stores the routes registered for a host pattern.
+	hostRoutes struct {
+		host     string                // the host pattern
+		matcher  routeStore            // the store matching the host pattern only
+		stores   map[string]routeStore // route stores indexed by HTTP methods
+		patterns *patternIndex         // the route patterns registered for the host
+		params   int                   // the number of parameters in the host pattern
+	}
+
+	// patternIndex stores the distinct route patterns of a host (or of all hosts) and the methods registered with them.
+	// It is used to find the HTTP methods allowed for a path without matching the path against every method store.
+	patternIndex struct {
+		store   routeStore
+		entries map[string]*patternEntry
+	}
+
+	// patternEntry represents a route pattern and the methods registered with it.
+	patternEntry struct {
+		elements []patternElement
+		methods  map[string]bool
+		index    *patternIndex
+		once     sync.Once
+		isolated bool // whether no other pattern in the index may match a path matching this pattern
+	}
+
+	// mount represents a router mounted under a path prefix via RouteGroup.Mount.
+	mount struct {
+		template string  // the URL template of the path prefix
+		router   *Router // the mounted router
+	}
+)
+
+// newRouteTable creates a new empty routeTable.
+func newRouteTable() *routeTable {
+	return &routeTable{
+		namedRoutes: make(map[string]*Route),
+		stores:      make(map[string]routeStore),
+		patterns:    newPatternIndex(),
+	}
+}
+
+// clone creates a copy of the table that can be modified without affecting the current one.
+func (t *routeTable) clone() *routeTable {
+	c := newRouteTable()
+	c.routes = make([]*Route, len(t.routes))
+	copy(c.routes, t.routes)
+	for name, route := range t.namedRoutes {
+		c.namedRoutes[name] = route
+	}
+	c.mounts = make([]*mount, len(t.mounts))
+	copy(c.mounts, t.mounts)
+	c.scopes = make([]*groupScope, len(t.scopes))
+	copy(c.scopes, t.scopes)
+	c.rebuild()
+	return c
+}
+
+// rebuild recreates the stores from the routes in the table.
+func (t *routeTable) rebuild() {
+	t.stores = make(map[string]routeStore)
+	t.patterns = newPatternIndex()
+	t.hosts = nil
+	t.maxParams = 0
+	for _, route := range t.routes {
+		t.store(route)
+	}
+	for _, s := range t.scopes {
+		if s.params > t.maxParams {
+			t.maxParams = s.params
+		}
+	}
+}
+
+// add adds the route to the table.
+func (t *routeTable) add(route *Route) {
+	t.routes = append(t.routes, route)
+	t.store(route)
+}
+
+// remove removes the route from the table. It returns false if the route cannot be found.
+func (t *routeTable) remove(route *Route) bool {
+	for i, r := range t.routes {
+		if r == route {
+			t.routes = append(t.routes[:i:i], t.routes[i+1:]...)
+			t.unname(route)
+			t.rebuild()
+			return true
+		}
+	}
+	return false
+}
+
+// replace replaces the route with a new one at the same position. It returns false if the route cannot be found.
+func (t *routeTable) replace(route, newRoute *Route) bool {
+	for i, r := range t.routes {
+		if r == route {
+			t.routes[i] = newRoute
+			for name, named := range t.namedRoutes {
+				if named == route {
+					t.namedRoutes[name] = newRoute
+				}
+			}
+			t.rebuild()
+			return true
+		}
+	}
+	return false
+}
+
+// unname removes the named route entries referring to the route.
+func (t *routeTable) unname(route *Route) {
+	for name, named := range t.namedRoutes {
+		if named == route {
+			delete(t.namedRoutes, name)
+		}
+	}
+}
+
+// store adds the route to the store matching its host and method.
+func (t *routeTable) store(route *Route) {
+	stores, patterns, hostParams := t.stores, t.patterns, 0
+	if route.group.host != "" {
+		hr := t.addHost(route.group.host)
+		stores, patterns, hostParams = hr.stores, hr.patterns, hr.params
+	}
+	store := stores[route.method]
+	if store == nil {
+		store = newStore()
+		stores[route.method] = store
+	}
+
+	// an asterisk at the end matches any number of characters
+	path := route.group.prefix + route.path
+	if strings.HasSuffix(path, "*") {
+		path = path[:len(path)-1] + "<:.*>"
+	}
+
+	if n := hostParams + store.Add(path, route); n > t.maxParams {
+		t.maxParams = n
+	}
+	patterns.add(path, route.method)
+}
+
+// addHost returns the route stores for the given host pattern, creating them if they do not exist yet.
+func (t *routeTable) addHost(host string) *hostRoutes {
+	for _, hr := range t.hosts {
+		if hr.host == host {
+			return hr
+		}
+	}
+	hr := &hostRoutes{
+		host:     host,
+		matcher:  newStore(),
+		stores:   make(map[string]routeStore),
+		patterns: newPatternIndex(),
+	}
+	hr.params = hr.matcher.Add(buildHostPattern(host), hr)
+	t.hosts = append(t.hosts, hr)
+	return hr
+}
+
+// addScope registers the host and the path prefix of the route group so that the group can be found via findGroup.
+// Groups with longer prefixes are tried first, and for the same prefix, groups with a host pattern are tried first.
+func (t *routeTable) addScope(rg *RouteGroup) {
+	for _, s := range t.scopes {
+		if s.group == rg {
+			return
+		}
+	}
+	s := &groupScope{
+		group:  rg,
+		prefix: newStore(),
+	}
+	prefix := strings.TrimRight(rg.prefix, "/")
+	if prefix != "" {
+		s.params = s.prefix.Add(prefix, rg)
+	}
+	if n := s.prefix.Add(prefix+"/<:.*>", rg); n > s.params {
+		s.params = n
+	}
+	if rg.host != "" {
+		s.host = newStore()
+		s.params += s.host.Add(buildHostPattern(rg.host), rg)
+	}
+	if s.params > t.maxParams {
+		t.maxParams = s.params
+	}
+	t.scopes = append(t.scopes, s)
+	sort.SliceStable(t.scopes, func(i, j int) bool {
+		gi, gj := t.scopes[i].group, t.scopes[j].group
+		if len(gi.prefix) != len(gj.prefix) {
+			return len(gi.prefix) > len(gj.prefix)
+		}
+		return gi.host != "" && gj.host == ""
+	})
+}
+
+// findGroup returns the route group with the longest path prefix matching the given host and path
+// among the groups registered via addScope that satisfy the given condition. Nil is returned if there is no such group.
+func (t *routeTable) findGroup(host, path string, pvalues []string, cond func(*RouteGroup) bool) *RouteGroup {
+	for _, s := range t.scopes {
+		if !cond(s.group) {
+			continue
+		}
+		values := pvalues
+		if s.host != nil {
+			if host == "" {
+				continue
+			}
+			data, hnames := s.host.Get(host, values)
+			if data == nil {
+				continue
+			}
+			values = values[len(hnames):]
+		}
+		if data, _ := s.prefix.Get(path, values); data != nil {
+			return s.group
+		}
+	}
+	return nil
+}
+
+// find returns the route matching the given method, host and path. Nil is returned if there is no matching route.
+func (t *routeTable) find(method, host, path string, pvalues []string) (*Route, []string) {
+	if len(t.hosts) > 0 && host != "" {
+		if route, pnames := t.findHost(method, host, path, pvalues); route != nil {
+			return route, pnames
+		}
+	}
+	if store := t.stores[method]; store != nil {
+		if data, pnames := store.Get(path, pvalues); data != nil {
+			return data.(*Route), pnames
+		}
+	}
+	return nil, nil
+}
+
+// findHost finds the route registered via Router.Host that matches the given host, method and path.
+// Host patterns are tried in the order they were registered.
+// The host parameters precede the path parameters in pvalues and the returned pnames.
+func (t *routeTable) findHost(method, host, path string, pvalues []string) (*Route, []string) {
+	for _, hr := range t.hosts {
+		store := hr.stores[method]
+		if store == nil {
+			continue
+		}
+		if data, hnames := hr.matcher.Get(host, pvalues); data != nil {
+			if data, pnames := store.Get(path, pvalues[len(hnames):]); data != nil {
+				return data.(*Route), combineNames(hnames, pnames)
+			}
+		}
+	}
+	return nil, nil
+}
+
+// findAllowedMethods returns the methods of the routes matching the given host and path.
+func (t *routeTable) findAllowedMethods(host, path string) map[string]bool {
+	methods := make(map[string]bool)
+	pvalues := make([]string, t.maxParams)
+	if host != "" {
+		for _, hr := range t.hosts {
+			if data, hnames := hr.matcher.Get(host, pvalues); data != nil {
+				hr.patterns.findMethods(path, hr.stores, pvalues[len(hnames):], methods)
+			}
+		}
+	}
+	t.patterns.findMethods(path, t.stores, pvalues, methods)
+	return methods
+}
+
+// newPatternIndex creates a new empty patternIndex.
+func newPatternIndex() *patternIndex {
+	return &patternIndex{
+		store:   newStore(),
+		entries: make(map[string]*patternEntry),
+	}
+}
+
+// add registers the method with the given route pattern.
+func (p *patternIndex) add(pattern, method string) {
+	if e, ok := p.entries[pattern]; ok {
+		e.methods[method] = true
+		return
+	}
+	e := &patternEntry{
+		elements: parsePattern(pattern),
+		methods:  map[string]bool{method: true},
+		index:    p,
+	}
+	p.entries[pattern] = e
+	p.store.Add(pattern, e)
+}
+
+// findMethods adds the methods of the routes in the given stores that match the path to methods.
+// If the pattern matching the path cannot overlap with any other pattern, its methods are used directly
+// instead of matching the path against every store.
+func (p *patternIndex) findMethods(path string, stores map[string]routeStore, pvalues []string, methods map[string]bool) {
+	data, _ := p.store.Get(path, pvalues)
+	if data == nil {
+		return
+	}
+	if e := data.(*patternEntry); e.isIsolated() {
+		for m := range e.methods {
+			methods[m] = true
+		}
+		return
+	}
+	for m, store := range stores {
+		if data, _ := store.Get(path, pvalues); data != nil {
+			methods[m] = true
+		}
+	}
+}
+
+// isIsolated checks if no other pattern in the index may match a path matching the pattern of the entry.
+// The result is computed when it is first needed and cached afterwards.
+func (e *patternEntry) isIsolated() bool {
+	e.once.Do(func() {
+		e.isolated = true
+		for _, other := range e.index.entries {
+			if other != e && !disjointPatterns(e.elements, other.elements) {
+				e.isolated = false
+				return
+			}
+		}
+	})
+	return e.isolated
+}
@@ -0,0 +1,198 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serveTable(r *Router, method, path string) (int, string) {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, nil)
+	r.ServeHTTP(res, req)
+	return res.Code, res.Body.String()
+}
+
+func writeHandler(s string) Handler {
+	return func(c *Context) error {
+		fmt.Fprint(c.ResponseWriter, s)
+		return nil
+	}
+}
+
+func TestRouterRemove(t *testing.T) {
+	r := New()
+	users := r.Get("/users/<id>", writeHandler("user")).Name("user")
+	r.Get("/users/profile", writeHandler("profile"))
+	items := r.To("GET,POST", "/items", writeHandler("items")).Name("items")
+
+	_, body := serveTable(r, "GET", "/users/profile")
+	assert.Equal(t, "user", body)
+
+	assert.True(t, r.Remove(users))
+	assert.False(t, r.Remove(users))
+	assert.Nil(t, r.Route("user"))
+	_, body = serveTable(r, "GET", "/users/profile")
+	assert.Equal(t, "profile", body)
+	code, _ := serveTable(r, "GET", "/users/1")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	assert.True(t, r.Remove(items))
+	assert.Nil(t, r.Route("items"))
+	code, _ = serveTable(r, "POST", "/items")
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, 1, len(r.Routes()))
+}
+
+func TestRouterReplace(t *testing.T) {
+	r := New()
+	r.Use(writeHandler("m,"))
+	old := r.Get("/users/<id>", writeHandler("v1")).Name("user").Tag("tag")
+	r.Get("/users/<name>", writeHandler("shadowed"))
+
+	route := r.Replace(old, writeHandler("v2"))
+	if assert.NotNil(t, route) {
+		assert.Equal(t, "/users/1", r.Route("user").URL("id", 1))
+		assert.Equal(t, route, r.Route("user"))
+		assert.Equal(t, []interface{}{"tag"}, route.Tags())
+	}
+	// the replaced route keeps its position and takes precedence over the later route
+	_, body := serveTable(r, "GET", "/users/1")
+	assert.Equal(t, "m,v2", body)
+	assert.Nil(t, r.Replace(old, writeHandler("v3")))
+
+	composite := r.To("PUT,DELETE", "/items", writeHandler("v1"))
+	composite = r.Replace(composite, writeHandler("v2"))
+	if assert.NotNil(t, composite) {
+		_, body = serveTable(r, "DELETE", "/items")
+		assert.Equal(t, "m,v2", body)
+	}
+}
+
+func TestRouterReplaceRouteHandlers(t *testing.T) {
+	r := New()
+	r.Use(writeHandler("m,"))
+	var calls []string
+	old := r.Get("/users", writeHandler("v1")).
+		Use(writeHandler("use,")).
+		AppendStartupHandler(writeHandler("startup,")).
+		AppendShutdownHandler(func(c *Context) error {
+			calls = append(calls, "shutdown")
+			return nil
+		})
+
+	route := r.Replace(old, writeHandler("v2"))
+	if assert.NotNil(t, route) {
+		_, body := serveTable(r, "GET", "/users")
+		assert.Equal(t, "startup,m,use,v2", body)
+		assert.Equal(t, []string{"shutdown"}, calls)
+
+		// the handlers registered via Use are kept when the route is replaced again
+		route.Use(writeHandler("use2,"))
+		r.Replace(route, writeHandler("v3"))
+		_, body = serveTable(r, "GET", "/users")
+		assert.Equal(t, "startup,m,use,use2,v3", body)
+	}
+
+	composite := r.To("PUT,DELETE", "/items", writeHandler("v1")).Use(writeHandler("use,"))
+	r.Replace(composite, writeHandler("v2"))
+	_, body := serveTable(r, "DELETE", "/items")
+	assert.Equal(t, "m,use,v2", body)
+}
+
+func TestRouterBatch(t *testing.T) {
+	r := New()
+	r.Get("/", writeHandler("root"))
+	assert.Equal(t, 1, len(r.Routes()))
+	r.batch(func() {
+		r.Get("/users", writeHandler("users"))
+		r.Mount("/api", New())
+		// the changes are not published until the batch ends
+		assert.Equal(t, 1, len(r.Routes()))
+		code, _ := serveTable(r, "GET", "/users")
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+	assert.Equal(t, 2+2*len(Methods), len(r.Routes()))
+	_, body := serveTable(r, "GET", "/users")
+	assert.Equal(t, "users", body)
+}
+
+func TestRouterConcurrentUpdate(t *testing.T) {
+	r := New()
+	r.Get("/", writeHandler("root"))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_, body := serveTable(r, "GET", "/")
+				assert.Equal(t, "root", body)
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		route := r.Get(fmt.Sprintf("/p%v/<a>/<b>/<c>", i), writeHandler("p"))
+		if i%2 == 0 {
+			r.Remove(route)
+		}
+	}
+	wg.Wait()
+	assert.Equal(t, 26, len(r.Routes()))
+}
+
+func TestRouterMaxParamsGrow(t *testing.T) {
+	r := New()
+	r.Get("/<a>", func(c *Context) error {
+		fmt.Fprint(c.ResponseWriter, c.Param("a"))
+		return nil
+	})
+	_, body := serveTable(r, "GET", "/x")
+	assert.Equal(t, "x", body)
+
+	// contexts created before the route is added must be resized
+	r.Get("/<a>/<b>/<c>", func(c *Context) error {
+		fmt.Fprint(c.ResponseWriter, c.Param("a")+c.Param("b")+c.Param("c"))
+		return nil
+	})
+	_, body = serveTable(r, "GET", "/x/y/z")
+	assert.Equal(t, "xyz", body)
+}
+
+func TestRouterAllowedMethods(t *testing.T) {
+	r := New()
+	r.Get("/users/<id>", func(c *Context) error { return nil })
+	r.Post("/users/new", func(c *Context) error { return nil })
+	r.To("GET,PUT", "/items/<name>", func(c *Context) error { return nil })
+	r.Host("api.example.com").Delete("/items/<name>", func(c *Context) error { return nil })
+
+	assert.Equal(t, []string{"GET", "OPTIONS"}, r.AllowedMethods("/users/1"))
+	assert.Equal(t, []string{"GET", "OPTIONS", "POST"}, r.AllowedMethods("/users/new"))
+	assert.Equal(t, []string{"GET", "OPTIONS", "PUT"}, r.AllowedMethods("/items/a"))
+	assert.Equal(t, []string{"DELETE", "GET", "OPTIONS", "PUT"}, r.AllowedMethods("/items/a", "API.example.com:8080"))
+	assert.Nil(t, r.AllowedMethods("/unknown"))
+	assert.Nil(t, r.AllowedMethods("/items/a/"))
+
+	r.IgnoreTrailingSlash = true
+	assert.Equal(t, []string{"GET", "OPTIONS", "PUT"}, r.AllowedMethods("/items/a/"))
+
+	r.AutoHead = true
+	assert.Equal(t, []string{"GET", "HEAD", "OPTIONS", "PUT"}, r.AllowedMethods("/items/a"))
+
+	// the 405 response uses the same path as route matching
+	r.UseEscapedPath = true
+	r.Use(MethodNotAllowedHandler)
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/items/a%2Fb/", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS, PUT", res.Header().Get("Allow"))
+}
@@ -0,0 +1,389 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validator validates the data populated by Context.Read and ReadFormData.
+type Validator interface {
+	// Validate validates the data, which is usually a pointer to a struct. It returns nil if the data is valid.
+	Validate(data interface{}) error
+}
+
+// ValidationRule checks a struct field value against the rule parameter given in the "validate" tag,
+// such as "64" in "max=64". The value is dereferenced if it is a non-nil pointer.
+// It returns an error describing why the value is invalid, or nil if the value is valid.
+type ValidationRule func(value reflect.Value, param string) error
+
+// ValidationError describes a struct field that fails validation.
+type ValidationError struct {
+	Field   string `json:"field" xml:"field"`     // the path of the field, such as "items[0].name"
+	Rule    string `json:"rule" xml:"rule"`       // the name of the failing rule
+	Message string `json:"message" xml:"message"` // the reason why the field is invalid
+}
+
+// ValidationErrors lists the struct fields that fail validation. It implements HTTPError with the 422 HTTP status.
+type ValidationErrors []ValidationError
+
+// DefaultValidator is the validator used by Context.Read and ReadFormData to validate the populated data.
+// By default, struct fields are validated according to their "validate" tags using TagValidator.
+// You may replace it with a validator based on another library, or set it nil to disable validation.
+var DefaultValidator Validator = &TagValidator{}
+
+// validationRules lists the rules that can be used in "validate" tags.
+var validationRules = map[string]ValidationRule{
+	"required": validateRequired,
+	"min":      validateMin,
+	"max":      validateMax,
+	"len":      validateLen,
+	"email":    validateEmail,
+	"url":      validateURL,
+	"oneof":    validateOneOf,
+}
+
+// RegisterValidationRule registers a rule that can be used in "validate" tags under the given name.
+// A rule registered with the name of a built-in rule replaces it.
+// RegisterValidationRule should be called before any data is validated. It is not thread safe.
+func RegisterValidationRule(name string, rule ValidationRule) {
+	validationRules[name] = rule
+}
+
+// Error returns the error message listing the invalid fields.
+func (es ValidationErrors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Field + ": " + e.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// StatusCode returns the HTTP status code.
+func (es ValidationErrors) StatusCode() int {
+	return http.StatusUnprocessableEntity
+}
+
+// TagValidator validates struct fields according to their "validate" tags, such as
+//
+//	Name  string `validate:"required,max=64"`
+//	Email string `validate:"email"`
+//	Role  string `validate:"oneof=admin editor viewer"`
+//
+// The following rules are supported, and more can be registered via RegisterValidationRule:
+//
+//   - required: the value must not be a zero value, a nil pointer or an empty string, slice or map
+//   - min, max: the minimum or maximum of a number, or of the length of a string, slice or map
+//   - len: the exact length of a string, slice or map
+//   - email, url: the string must be a valid email address or absolute URL
+//   - oneof: the value must be one of the space separated values
+//
+// Rules other than "required" are not applied to zero values, so that optional fields may be omitted.
+// Nested structs, and structs in slices and maps, are validated as well, except the values referring back
+// to a value containing them. The fields are named after their "json" or "form" tags, or their names if there is no tag.
+// The first failing rule of every field is reported.
+//
+// Rules that are not registered, such as those meant for another validation library, are ignored unless Strict is set.
+type TagValidator struct {
+	// Strict makes Validate return an error, instead of ignoring the rule, if a tag uses a rule that is not registered.
+	Strict bool
+}
+
+const validateTag = "validate"
+
+// validation holds the state of a validation performed by TagValidator.
+type validation struct {
+	strict   bool
+	errs     ValidationErrors
+	err      error                    // the error of the first unknown rule found in strict mode
+	visiting map[validationVisit]bool // the references on the current validation path, used to detect reference cycles
+}
+
+// validationVisit identifies a pointer, map or slice being validated.
+type validationVisit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// Validate validates the data and returns ValidationErrors listing all invalid fields, or nil if the data is valid.
+// If Strict is set and a tag uses a rule that is not registered, an error reporting the rule is returned instead.
+func (v *TagValidator) Validate(data interface{}) error {
+	s := &validation{strict: v.Strict}
+	s.validateValue(reflect.ValueOf(data), "")
+	if s.err != nil {
+		return s.err
+	}
+	if len(s.errs) > 0 {
+		return s.errs
+	}
+	return nil
+}
+
+// validate validates the data using DefaultValidator.
+func validate(data interface{}) error {
+	if DefaultValidator == nil {
+		return nil
+	}
+	return DefaultValidator.Validate(data)
+}
+
+// validateValue validates the structs contained in the value.
+func (s *validation) validateValue(rv reflect.Value, path string) {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return
+		}
+		if rv.Kind() == reflect.Ptr {
+			if !s.enter(rv) {
+				return
+			}
+			defer s.leave(rv)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Map {
+		if !s.enter(rv) {
+			return
+		}
+		defer s.leave(rv)
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		s.validateStruct(rv, path)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			s.validateValue(rv.Index(i), fmt.Sprintf("%v[%v]", path, i))
+		}
+	case reflect.Map:
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		for _, key := range keys {
+			s.validateValue(rv.MapIndex(key), fmt.Sprintf("%v[%v]", path, key))
+		}
+	}
+}
+
+// enter marks the pointer, map or slice as being validated. It returns false if the value is already being validated,
+// which means it refers to itself directly or indirectly and should not be validated again.
+func (s *validation) enter(rv reflect.Value) bool {
+	visit := newValidationVisit(rv)
+	if s.visiting[visit] {
+		return false
+	}
+	if s.visiting == nil {
+		s.visiting = make(map[validationVisit]bool)
+	}
+	s.visiting[visit] = true
+	return true
+}
+
+// leave marks the pointer, map or slice as no longer being validated.
+func (s *validation) leave(rv reflect.Value) {
+	delete(s.visiting, newValidationVisit(rv))
+}
+
+// newValidationVisit creates the validationVisit identifying the pointer, map or slice.
+func newValidationVisit(rv reflect.Value) validationVisit {
+	visit := validationVisit{rv.Pointer(), rv.Type(), 0}
+	if rv.Kind() == reflect.Slice {
+		visit.len = rv.Len()
+	}
+	return visit
+}
+
+// validateStruct validates the fields of the struct according to their "validate" tags.
+func (s *validation) validateStruct(rv reflect.Value, path string) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag := field.Tag.Get(validateTag)
+
+		// only handle anonymous or exported fields
+		if !field.Anonymous && field.PkgPath != "" || tag == "-" {
+			continue
+		}
+
+		name := validationFieldName(field)
+		if field.Anonymous && name == "" {
+			// the fields of an embedded struct belong to the parent struct
+			s.validateValue(rv.Field(i), path)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if path != "" {
+			name = path + "." + name
+		}
+
+		if tag != "" {
+			if err := s.validateField(rv.Field(i), tag, name); err != nil {
+				s.errs = append(s.errs, *err)
+				continue
+			}
+		}
+		s.validateValue(rv.Field(i), name)
+	}
+}
+
+// validationFieldName returns the name of the field as given by its "json" or "form" tag.
+// An empty string is returned if the field has neither tag.
+func validationFieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", formTag} {
+		tag := field.Tag.Get(key)
+		if i := strings.IndexByte(tag, ','); i >= 0 {
+			tag = tag[:i]
+		}
+		if tag != "" && tag != "-" {
+			return tag
+		}
+	}
+	return ""
+}
+
+// validateField applies the rules in the tag to the field value and returns the error of the first failing rule.
+// Unknown rules are skipped, and the first one is recorded as an error in strict mode.
+func (s *validation) validateField(rv reflect.Value, tag, name string) *ValidationError {
+	empty := isEmptyValue(rv)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	for _, r := range strings.Split(tag, ",") {
+		rule, param := r, ""
+		if i := strings.IndexByte(r, '='); i >= 0 {
+			rule, param = r[:i], r[i+1:]
+		}
+		if rule == "" || empty && rule != "required" {
+			continue
+		}
+		f, ok := validationRules[rule]
+		if !ok {
+			if s.strict && s.err == nil {
+				s.err = fmt.Errorf("unknown validation rule %q used by field %v", rule, name)
+			}
+			continue
+		}
+		if err := f(rv, param); err != nil {
+			return &ValidationError{Field: name, Rule: rule, Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+// isEmptyValue checks if the value is a zero value, a nil pointer or an empty string, slice or map.
+func isEmptyValue(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return rv.IsNil()
+	case reflect.Struct:
+		return reflect.DeepEqual(rv.Interface(), reflect.Zero(rv.Type()).Interface())
+	}
+	return false
+}
+
+func validateRequired(rv reflect.Value, param string) error {
+	if isEmptyValue(rv) {
+		return errors.New("is required")
+	}
+	return nil
+}
+
+func validateMin(rv reflect.Value, param string) error {
+	return compareValue(rv, param, func(v, limit float64) bool { return v >= limit }, "must be no less than %v", "must contain at least %v")
+}
+
+func validateMax(rv reflect.Value, param string) error {
+	return compareValue(rv, param, func(v, limit float64) bool { return v <= limit }, "must be no greater than %v", "must contain at most %v")
+}
+
+func validateLen(rv reflect.Value, param string) error {
+	return compareValue(rv, param, func(v, limit float64) bool { return v == limit }, "must be %v", "must contain exactly %v")
+}
+
+// compareValue compares a number, or the length of a string, slice or map, with the limit given as the rule parameter.
+// The number format is used in the error message for numbers, and the length format for lengths.
+func compareValue(rv reflect.Value, param string, ok func(v, limit float64) bool, numberFormat, lengthFormat string) error {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return fmt.Errorf("has an invalid rule parameter %q", param)
+	}
+	var (
+		v      float64
+		format = numberFormat
+		unit   = ""
+	)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v = float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v = float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		v = rv.Float()
+	case reflect.String:
+		v, format, unit = float64(utf8.RuneCountInString(rv.String())), lengthFormat, " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		v, format, unit = float64(rv.Len()), lengthFormat, " items"
+	default:
+		return fmt.Errorf("cannot be compared with %v", param)
+	}
+	if !ok(v, limit) {
+		return fmt.Errorf(format, param+unit)
+	}
+	return nil
+}
+
+func validateEmail(rv reflect.Value, param string) error {
+	if rv.Kind() == reflect.String {
+		if addr, err := mail.ParseAddress(rv.String()); err == nil && addr.Address == rv.String() {
+			return nil
+		}
+	}
+	return errors.New("must be a valid email address")
+}
+
+func validateURL(rv reflect.Value, param string) error {
+	if rv.Kind() == reflect.String {
+		if u, err := url.Parse(rv.String()); err == nil && u.Scheme != "" && u.Host != "" {
+			return nil
+		}
+	}
+	return errors.New("must be a valid URL")
+}
+
+func validateOneOf(rv reflect.Value, param string) error {
+	values := strings.Fields(param)
+	s := fmt.Sprint(rv.Interface())
+	for _, value := range values {
+		if s == value {
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of %v", strings.Join(values, ", "))
+}
@@ -0,0 +1,154 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type validatedItem struct {
+	Name  string `json:"name" validate:"required"`
+	Count int    `json:"count" validate:"min=1,max=10"`
+}
+
+type validatedOrder struct {
+	ID       int                      `json:"id" form:"id" validate:"required"`
+	Customer string                   `json:"customer" form:"customer" validate:"required,min=2,max=8"`
+	Email    string                   `json:"email" form:"email" validate:"email"`
+	Site     *string                  `json:"site" validate:"url"`
+	Status   string                   `json:"status" form:"status" validate:"oneof=new paid"`
+	Code     string                   `form:"code" validate:"len=3"`
+	Items    []validatedItem          `json:"items" validate:"required"`
+	Extra    map[string]validatedItem `json:"extra"`
+	Note     string
+	validatedEmbedded
+}
+
+type validatedEmbedded struct {
+	Level int `json:"level" validate:"max=3"`
+}
+
+func TestTagValidator(t *testing.T) {
+	v := &TagValidator{}
+	site := "example.com"
+	order := validatedOrder{
+		Customer: "a very long name",
+		Email:    "john",
+		Site:     &site,
+		Status:   "sent",
+		Code:     "ab",
+		Items:    []validatedItem{{"a", 1}, {"", 20}},
+		Extra:    map[string]validatedItem{"x": {"x", 0}, "y": {"y", 11}},
+	}
+	order.Level = 5
+	err := v.Validate(&order)
+	if assert.NotNil(t, err) {
+		errs, ok := err.(ValidationErrors)
+		if assert.True(t, ok) {
+			assert.Equal(t, ValidationErrors{
+				{"id", "required", "is required"},
+				{"customer", "max", "must contain at most 8 characters"},
+				{"email", "email", "must be a valid email address"},
+				{"site", "url", "must be a valid URL"},
+				{"status", "oneof", "must be one of new, paid"},
+				{"code", "len", "must contain exactly 3 characters"},
+				{"items[1].name", "required", "is required"},
+				{"items[1].count", "max", "must be no greater than 10"},
+				{"extra[y].count", "max", "must be no greater than 10"},
+				{"level", "max", "must be no greater than 3"},
+			}, errs)
+			assert.Equal(t, http.StatusUnprocessableEntity, errs.StatusCode())
+		}
+	}
+
+	site = "https://example.com"
+	valid := validatedOrder{
+		ID:       1,
+		Customer: "john",
+		Email:    "john@example.com",
+		Site:     &site,
+		Status:   "new",
+		Items:    []validatedItem{{"a", 1}},
+	}
+	assert.Nil(t, v.Validate(&valid))
+	assert.Nil(t, v.Validate(nil))
+	assert.Nil(t, v.Validate(10))
+
+	// unknown rules are ignored unless the validator is strict
+	unknown := &struct {
+		A int    `validate:"gte=1"`
+		B string `validate:"required,unknown"`
+	}{0, ""}
+	assert.Equal(t, ValidationErrors{{"B", "required", "is required"}}, v.Validate(unknown))
+	unknown.B = "b"
+	assert.Nil(t, v.Validate(unknown))
+	err = (&TagValidator{Strict: true}).Validate(unknown)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, `unknown validation rule "unknown" used by field B`, err.Error())
+	}
+}
+
+func TestTagValidatorCycles(t *testing.T) {
+	type node struct {
+		Name     string                 `json:"name" validate:"required"`
+		Next     *node                  `json:"next"`
+		Children []*node                `json:"children"`
+		Extra    map[string]interface{} `json:"extra"`
+	}
+	a := &node{Name: "a"}
+	b := &node{Next: a}
+	a.Next = b
+	a.Children = []*node{a, b}
+	a.Extra = map[string]interface{}{"self": a.Extra}
+	a.Extra["self"] = a.Extra
+	assert.Equal(t, ValidationErrors{
+		{"next.name", "required", "is required"},
+		{"children[1].name", "required", "is required"},
+	}, (&TagValidator{}).Validate(a))
+}
+
+func TestRegisterValidationRule(t *testing.T) {
+	RegisterValidationRule("even", func(value reflect.Value, param string) error {
+		if value.Kind() == reflect.Int && value.Int()%2 != 0 {
+			return errors.New("must be even")
+		}
+		return nil
+	})
+	defer delete(validationRules, "even")
+
+	data := struct {
+		A int `validate:"even"`
+		B int `validate:"even"`
+	}{3, 4}
+	assert.Equal(t, ValidationErrors{{"A", "even", "must be even"}}, (&TagValidator{}).Validate(&data))
+	assert.Equal(t, "A: must be even", ValidationErrors{{"A", "even", "must be even"}}.Error())
+}
+
+func TestReadValidation(t *testing.T) {
+	var order validatedOrder
+	err := ReadFormData(map[string][]string{"id": {"1"}, "customer": {"x"}}, &order)
+	assert.Equal(t, ValidationErrors{
+		{"customer", "min", "must contain at least 2 characters"},
+		{"items", "required", "is required"},
+	}, err)
+
+	req, _ := http.NewRequest("POST", "/orders", bytes.NewBufferString(`{"id":1,"customer":"john","items":[{"name":"a","count":-1}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	c := NewContext(nil, req)
+	err = c.Read(&order)
+	assert.Equal(t, ValidationErrors{{"items[0].count", "min", "must be no less than 1"}}, err)
+
+	// validation can be disabled
+	validator := DefaultValidator
+	DefaultValidator = nil
+	defer func() { DefaultValidator = validator }()
+	assert.Nil(t, ReadFormData(map[string][]string{"customer": {"x"}}, &order))
+}